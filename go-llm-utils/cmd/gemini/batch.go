@@ -0,0 +1,321 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/batch"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+)
+
+const batchHelp = `Each line of the requests file is a JSON object:
+  {"id": "greet", "prompt_file": "user/hello.md", "vars": {"name": "Ada"},
+   "system": "Be brief.", "model": "models/gemini-2.5-flash", "profile": "default",
+   "seed": 5, "temperature": 0.7}
+Give the prompt with "prompt" or "prompt_file" and the optional system
+instruction with "system" or "system_file"; file paths are relative to the
+prompts directory. Results are appended to the output file as they finish;
+running the same command again retries only requests without a result.`
+
+const batchSubmitHelp = batchHelp + `
+
+submit sends the pending requests to the Gemini Batch API as one job per
+model and records the jobs in a manifest. Batch jobs cost less than
+individual calls but may take up to a day; use status to check on them and
+download to append their results to the same results file as run.`
+
+func batchCommand() *command {
+	return &command{
+		name:    "batch",
+		summary: "run many requests from a JSONL file",
+		subcommands: []*command{
+			{name: "run", args: "<requests.jsonl>", summary: "run requests locally with bounded concurrency", help: batchHelp, setup: batchRunCommand},
+			{name: "submit", args: "<requests.jsonl>", summary: "submit requests as Gemini Batch API jobs", help: batchSubmitHelp, setup: batchSubmitCommand},
+			{name: "status", args: "<manifest.job.json>", summary: "refresh and print the state of submitted jobs", setup: batchStatusCommand},
+			{name: "download", args: "<manifest.job.json>", summary: "append the results of finished jobs", setup: batchDownloadCommand},
+			{name: "cancel", args: "<manifest.job.json>", summary: "cancel submitted jobs", setup: batchCancelCommand},
+		},
+	}
+}
+
+// resultsFile names the default output file: requests.jsonl gives requests.results.jsonl.
+func resultsFile(input string) string {
+	return strings.TrimSuffix(input, filepath.Ext(input)) + ".results.jsonl"
+}
+
+func batchRunCommand(a *app, fs *flag.FlagSet) runFunc {
+	out := fs.String("out", "", "results file (default <input>.results.jsonl)")
+	concurrency := fs.Int("concurrency", batch.DefaultConcurrency, "maximum number of requests in flight")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a single requests file")
+		}
+		input := args[0]
+		output := *out
+		if output == "" {
+			output = resultsFile(input)
+		}
+
+		profiles, err := a.profiles()
+		if err != nil {
+			return err
+		}
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		runner := &batch.Runner{
+			Settings:    a.batchSettings(profiles),
+			Generator:   &gemini.GenAIContentGenerator{Client: client},
+			Concurrency: *concurrency,
+		}
+
+		stats, err := runner.RunFile(ctx, input, output)
+		if a.output == outputJSON {
+			if err := printJSON(stats); err != nil {
+				return err
+			}
+		}
+		a.logf("%d requests: %d skipped, %d succeeded, %d failed; results in %s",
+			stats.Total, stats.Skipped, stats.Succeeded, stats.Failed, output)
+		if err != nil {
+			return fmt.Errorf("batch run stopped: %w", err)
+		}
+		if stats.Failed > 0 {
+			return fmt.Errorf("%d requests failed; run the command again to retry them", stats.Failed)
+		}
+		return nil
+	}
+}
+
+// manifestFile names the default job manifest: requests.jsonl gives requests.job.json.
+func manifestFile(input string) string {
+	return strings.TrimSuffix(input, filepath.Ext(input)) + ".job.json"
+}
+
+// batchSettings resolves batch requests with the global flags and the project prompts directory.
+func (a *app) batchSettings(profiles gemini.Profiles) batch.Settings {
+	settings := batch.Settings{
+		Profiles: profiles,
+		Profile:  a.profile,
+		Model:    a.model,
+		Renderer: a.renderer(),
+	}
+	if dir, err := a.promptsDir(); err == nil {
+		settings.PromptsDir = dir
+	} else {
+		a.debugf(1, "prompt files resolve against the working directory: %v", err)
+	}
+	return settings
+}
+
+// submitter creates a batch job submitter backed by the Batch API.
+func (a *app) submitter(ctx context.Context) (*batch.Submitter, error) {
+	profiles, err := a.profiles()
+	if err != nil {
+		return nil, err
+	}
+	jobs := a.jobs
+	if jobs == nil {
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		jobs = &gemini.GenAIBatchJobClient{Client: client}
+	}
+	return &batch.Submitter{
+		Settings: a.batchSettings(profiles),
+		Jobs:     jobs,
+	}, nil
+}
+
+// printJobs reports the state of every job in the manifest.
+func (a *app) printJobs(m *batch.Manifest) error {
+	if a.output == outputJSON {
+		return printJSON(m)
+	}
+	for _, job := range m.Jobs {
+		line := fmt.Sprintf("%s\t%s\t%s\t%d requests", job.Name, job.Model, job.State, len(job.IDs))
+		if job.Error != "" {
+			line += "\t" + job.Error
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func batchSubmitCommand(a *app, fs *flag.FlagSet) runFunc {
+	manifest := fs.String("manifest", "", "job manifest file (default <input>.job.json)")
+	name := fs.String("name", "", "job display name (default the input file name)")
+	wait := fs.Bool("wait", false, "wait for the jobs to finish and download the results")
+	out := fs.String("out", "", "results file for -wait (default <input>.results.jsonl)")
+	poll := fs.Duration("poll", batch.DefaultPollInterval, "status polling interval for -wait")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a single requests file")
+		}
+		input := args[0]
+		manifestPath := *manifest
+		if manifestPath == "" {
+			manifestPath = manifestFile(input)
+		}
+		displayName := *name
+		if displayName == "" {
+			displayName = strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+		}
+
+		requests, err := batch.ReadRequestsFile(input)
+		if err != nil {
+			return err
+		}
+		done, err := batch.Completed(resultsFile(input))
+		if err != nil {
+			return err
+		}
+		pending := batch.Pending(requests, done)
+		if len(pending) == 0 {
+			a.logf("all %d requests already have results", len(requests))
+			return nil
+		}
+
+		submitter, err := a.submitter(ctx)
+		if err != nil {
+			return err
+		}
+		submitter.PollInterval = *poll
+
+		m, err := submitter.Submit(ctx, pending, displayName)
+		if m != nil && len(m.Jobs) > 0 {
+			m.Input = input
+			if saveErr := m.Save(manifestPath); saveErr != nil {
+				return saveErr
+			}
+			a.logf("submitted %d requests in %d jobs; manifest in %s", len(pending), len(m.Jobs), manifestPath)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !*wait {
+			return a.printJobs(m)
+		}
+		err = submitter.Wait(ctx, m, func(m *batch.Manifest) {
+			for _, job := range m.Jobs {
+				a.debugf(1, "%s: %s", job.Name, job.State)
+			}
+		})
+		if saveErr := m.Save(manifestPath); saveErr != nil {
+			return saveErr
+		}
+		if err != nil {
+			return fmt.Errorf("stopped waiting for batch jobs: %w", err)
+		}
+		return a.downloadResults(ctx, submitter, m, manifestPath, *out)
+	}
+}
+
+func batchStatusCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a single manifest file")
+		}
+		m, err := batch.LoadManifest(args[0])
+		if err != nil {
+			return err
+		}
+		submitter, err := a.submitter(ctx)
+		if err != nil {
+			return err
+		}
+		if err := submitter.Refresh(ctx, m); err != nil {
+			return err
+		}
+		if err := m.Save(args[0]); err != nil {
+			return err
+		}
+		return a.printJobs(m)
+	}
+}
+
+func batchDownloadCommand(a *app, fs *flag.FlagSet) runFunc {
+	out := fs.String("out", "", "results file (default <input>.results.jsonl)")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a single manifest file")
+		}
+		m, err := batch.LoadManifest(args[0])
+		if err != nil {
+			return err
+		}
+		submitter, err := a.submitter(ctx)
+		if err != nil {
+			return err
+		}
+		return a.downloadResults(ctx, submitter, m, args[0], *out)
+	}
+}
+
+func batchCancelCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a single manifest file")
+		}
+		m, err := batch.LoadManifest(args[0])
+		if err != nil {
+			return err
+		}
+		submitter, err := a.submitter(ctx)
+		if err != nil {
+			return err
+		}
+		err = submitter.Cancel(ctx, m)
+		if saveErr := m.Save(args[0]); saveErr != nil {
+			return saveErr
+		}
+		if err != nil {
+			return err
+		}
+		return a.printJobs(m)
+	}
+}
+
+// downloadResults appends the results of finished jobs to the results file.
+func (a *app) downloadResults(ctx context.Context, submitter *batch.Submitter, m *batch.Manifest, manifestPath, output string) error {
+	if output == "" {
+		if m.Input == "" {
+			return fmt.Errorf("manifest has no input file; use -out")
+		}
+		output = resultsFile(m.Input)
+	}
+
+	results, err := submitter.Results(ctx, m)
+	if saveErr := m.Save(manifestPath); saveErr != nil {
+		return saveErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := batch.AppendResults(output, results); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Error != "" {
+			failed++
+		}
+	}
+	a.logf("%d results, %d failed; results in %s", len(results), failed, output)
+	if failed > 0 {
+		return fmt.Errorf("%d requests failed; retry them with batch run or batch submit", failed)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"google.golang.org/genai"
+)
+
+const chatHelp = `type a message and press enter; commands:
+  /reset  start a new conversation
+  /exit   leave the chat`
+
+func chatCommand(a *app, fs *flag.FlagSet) runFunc {
+	var in promptInput
+	in.register(fs)
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unexpected arguments: %v", args)
+		}
+		if in.systemFile == "-" || in.file != "" {
+			return fmt.Errorf("chat reads messages from stdin; -file and -system-file - cannot be used")
+		}
+
+		profile, err := a.settings(gemini.DefaultProfile)
+		if err != nil {
+			return err
+		}
+		config, err := in.config(a, profile)
+		if err != nil {
+			return err
+		}
+		// Chat replies are shown as one conversation, so extra candidates are wasted.
+		config.CandidateCount = 1
+
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		chat, err := client.Chats.Create(ctx, profile.Model, config, nil)
+		if err != nil {
+			return fmt.Errorf("failed to start chat: %w", err)
+		}
+
+		a.logf("chatting with %s\n%s", profile.Model, chatHelp)
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for {
+			fmt.Fprint(os.Stderr, "> ")
+			if !scanner.Scan() {
+				break
+			}
+
+			message := strings.TrimSpace(scanner.Text())
+			switch message {
+			case "":
+				continue
+			case "/exit", "/quit":
+				return nil
+			case "/reset":
+				chat, err = client.Chats.Create(ctx, profile.Model, config, nil)
+				if err != nil {
+					return fmt.Errorf("failed to start chat: %w", err)
+				}
+				a.logf("started a new conversation")
+				continue
+			}
+
+			if err := a.sendChatMessage(ctx, chat, message); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		return nil
+	}
+}
+
+// sendChatMessage sends one message and prints the reply. A blocked or empty
+// reply is reported without ending the session.
+func (a *app) sendChatMessage(ctx context.Context, chat *genai.Chat, message string) error {
+	if a.output == outputJSON {
+		response, err := chat.SendMessage(ctx, genai.Part{Text: message})
+		if err != nil {
+			return fmt.Errorf("failed to send message: %w", err)
+		}
+		a.logReport(response)
+		if err := gemini.CheckResponse(response); err != nil {
+			log.Print(err)
+		}
+		return printJSON(response)
+	}
+
+	var last *genai.GenerateContentResponse
+	var printed bool
+	for chunk, err := range chat.SendMessageStream(ctx, genai.Part{Text: message}) {
+		if err != nil {
+			return fmt.Errorf("failed to send message: %w", err)
+		}
+		for _, cand := range chunk.Candidates {
+			if cand.Content == nil {
+				continue
+			}
+			for _, part := range cand.Content.Parts {
+				if !part.Thought && part.Text != "" {
+					fmt.Print(part.Text)
+					printed = true
+				}
+			}
+		}
+		last = chunk
+	}
+	if !printed {
+		log.Print(gemini.CheckResponse(last))
+		return nil
+	}
+	fmt.Println()
+	a.logReport(last)
+	return nil
+}
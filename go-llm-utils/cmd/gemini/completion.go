@@ -0,0 +1,175 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/eval"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+)
+
+// The completion scripts delegate to the hidden __complete command, which
+// prints the candidates for the last word given the words before it.
+var completionScripts = map[string]string{
+	"bash": `_gemini() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$(gemini __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)" -- "$cur"))
+}
+complete -o default -F _gemini gemini
+`,
+	"zsh": `#compdef gemini
+_gemini() {
+    local -a candidates
+    candidates=(${(f)"$(gemini __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    if (( ${#candidates} )); then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+compdef _gemini gemini
+`,
+	"fish": `complete -c gemini -f -a '(gemini __complete (commandline -opc | tail -n +2) (commandline -ct) 2>/dev/null)'
+`,
+}
+
+func completionCommand(_ *app, _ *flag.FlagSet) runFunc {
+	return func(_ context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a shell name: bash, zsh or fish")
+		}
+		script, ok := completionScripts[args[0]]
+		if !ok {
+			return fmt.Errorf("unsupported shell %q, want bash, zsh or fish", args[0])
+		}
+		fmt.Print(script)
+		return nil
+	}
+}
+
+func completeCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(_ context.Context, args []string) error {
+		for _, candidate := range a.complete(rootCommand(), args) {
+			fmt.Println(candidate)
+		}
+		return nil
+	}
+}
+
+// complete returns the candidates for the last of words, the command line
+// after the program name.
+func (a *app) complete(cmd *command, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, preceding := words[len(words)-1], words[:len(words)-1]
+
+	fs := a.flagSet(cmd)
+	for i := 0; i < len(preceding); i++ {
+		word := preceding[i]
+		if strings.HasPrefix(word, "-") {
+			if takesValue(fs, word) {
+				i++
+			}
+			continue
+		}
+		sub := cmd.subcommand(word)
+		if sub == nil {
+			// A positional argument: nothing more to descend into.
+			break
+		}
+		cmd = sub
+		fs = a.flagSet(cmd)
+	}
+
+	if len(preceding) > 0 {
+		previous := preceding[len(preceding)-1]
+		if takesValue(fs, previous) {
+			return a.flagValues(strings.TrimLeft(previous, "-"))
+		}
+	}
+
+	if strings.HasPrefix(current, "-") {
+		var names []string
+		fs.VisitAll(func(f *flag.Flag) {
+			names = append(names, "-"+f.Name)
+		})
+		return names
+	}
+
+	var names []string
+	for _, sub := range cmd.subcommands {
+		if !sub.hidden {
+			names = append(names, sub.name)
+		}
+	}
+	if cmd.name == "completion" {
+		names = []string{"bash", "fish", "zsh"}
+	}
+	return names
+}
+
+// flagSet builds the flags of cmd without running it.
+func (a *app) flagSet(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	scratch := *a
+	scratch.globalFlags(fs)
+	if cmd.setup != nil {
+		cmd.setup(&scratch, fs)
+	}
+	return fs
+}
+
+// takesValue reports whether word is a flag that consumes the next word.
+func takesValue(fs *flag.FlagSet, word string) bool {
+	if !strings.HasPrefix(word, "-") || strings.Contains(word, "=") {
+		return false
+	}
+	f := fs.Lookup(strings.TrimLeft(word, "-"))
+	if f == nil {
+		return false
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+// flagValues returns the known values of a flag, or nothing to let the shell
+// fall back to file names.
+func (a *app) flagValues(name string) []string {
+	switch name {
+	case "output":
+		return []string{outputText, outputJSON, outputRaw}
+	case "profile":
+		profiles, err := a.profiles()
+		if err != nil {
+			return nil
+		}
+		return profiles.Names()
+	case "select":
+		return gemini.SelectionStrategies()
+	case "all":
+		return []string{"files", "sections"}
+	case "format":
+		return gemini.Formats()
+	case "if-exists":
+		return gemini.ExistsModes()
+	case "golden":
+		return eval.GoldenModes()
+	case "rubric":
+		dir, err := a.promptsDir()
+		if err != nil {
+			return nil
+		}
+		names, _ := eval.Rubrics(dir)
+		return names
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"google.golang.org/genai"
+)
+
+// promptInput holds the flags shared by commands that send a prompt. Either
+// file may be "-" to read it from stdin, and a piped stdin is used as the
+// prompt when neither arguments nor -file are given.
+type promptInput struct {
+	vars       prompts.Vars
+	system     string
+	systemFile string
+	file       string
+}
+
+func (in *promptInput) register(fs *flag.FlagSet) {
+	in.vars = prompts.Vars{}
+	fs.Var(in.vars, "var", "prompt template variable as name=value (repeatable)")
+	fs.StringVar(&in.system, "system", "", "system instruction, overriding the profile")
+	fs.StringVar(&in.systemFile, "system-file", "", `file containing the system instruction, or "-" for stdin`)
+	fs.StringVar(&in.file, "file", "", `file containing the prompt, or "-" for stdin, instead of the arguments`)
+}
+
+// systemInstruction returns the system instruction from -system or
+// -system-file, or the empty string if neither is set.
+func (in *promptInput) systemInstruction(a *app) (string, error) {
+	if in.system != "" && in.systemFile != "" {
+		return "", fmt.Errorf("-system and -system-file are mutually exclusive")
+	}
+	if in.systemFile != "" {
+		text, err := in.read(a, in.systemFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read system instruction: %w", err)
+		}
+		return text, nil
+	}
+	return in.system, nil
+}
+
+// prompt returns the prompt from -file, the positional arguments or stdin.
+func (in *promptInput) prompt(a *app, args []string) (string, error) {
+	file := in.file
+	if file != "" && len(args) > 0 {
+		return "", fmt.Errorf("give the prompt either as arguments or with -file, not both")
+	}
+	if file == "" && len(args) == 0 && in.systemFile != "-" && stdinPiped() {
+		file = "-"
+	}
+	if file == "-" && in.systemFile == "-" {
+		return "", fmt.Errorf("stdin can provide either the prompt or the system instruction, not both")
+	}
+
+	var text string
+	var err error
+	if file != "" {
+		text, err = in.read(a, file)
+	} else {
+		text, err = a.renderer().RenderText("arguments", []byte(strings.Join(args, " ")), in.vars)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt: %w", err)
+	}
+	if text == "" {
+		return "", fmt.Errorf("prompt is empty")
+	}
+	return text, nil
+}
+
+// read renders a prompt file, or stdin if file is "-".
+func (in *promptInput) read(a *app, file string) (string, error) {
+	if file != "-" {
+		return a.renderer().RenderFile(file, in.vars)
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return a.renderer().RenderText("stdin", data, in.vars)
+}
+
+// stdinPiped reports whether stdin is a pipe or a file rather than a terminal.
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// config builds the request config from the profile and the system instruction flags.
+func (in *promptInput) config(a *app, profile gemini.Profile) (*genai.GenerateContentConfig, error) {
+	system, err := in.systemInstruction(a)
+	if err != nil {
+		return nil, err
+	}
+	if system != "" {
+		profile.SystemInstruction = system
+	}
+	return profile.GenerateContentConfig(), nil
+}
+
+func generateCommand(a *app, fs *flag.FlagSet) runFunc {
+	var in promptInput
+	in.register(fs)
+	out := fs.String("out", "", "also write the response to this file")
+	candidates := fs.Int("candidates", 0, "number of candidates to generate, overriding the profile")
+	selection := fs.String("select", "first", "candidate to print and write: "+strings.Join(gemini.SelectionStrategies(), ", "))
+	all := fs.String("all", "", `write every candidate to -out, as numbered "files" or one document with "sections"`)
+	ifExists := fs.String("if-exists", "overwrite", "when -out exists: "+strings.Join(gemini.ExistsModes(), ", "))
+	provenance := fs.Bool("provenance", true, "write a provenance sidecar next to -out for the reproduce command (not with -all)")
+	format := fs.String("format", "", "format of -out: "+strings.Join(gemini.Formats(), ", ")+" (default from the file extension)")
+
+	return func(ctx context.Context, args []string) error {
+		profile, err := a.settings(gemini.DefaultProfile)
+		if err != nil {
+			return err
+		}
+		if *candidates > 0 {
+			profile.CandidateCount = int32(*candidates)
+		}
+		score, err := gemini.SelectionStrategy(*selection)
+		if err != nil {
+			return err
+		}
+		if *all != "" && *all != "files" && *all != "sections" {
+			return fmt.Errorf(`-all must be "files" or "sections"`)
+		}
+		if *all != "" && *out == "" {
+			return fmt.Errorf("-all needs -out")
+		}
+		if *all != "" && *format != "" {
+			return fmt.Errorf("-all writes markdown and cannot be combined with -format")
+		}
+		if *all != "" && isSet(fs, "provenance") && *provenance {
+			return fmt.Errorf("-all writes no provenance sidecar; use -provenance only with a single response")
+		}
+		existsMode, err := gemini.ParseExistsMode(*ifExists)
+		if err != nil {
+			return err
+		}
+		var writer gemini.ResponseWriter
+		outFormat := *format
+		if *out != "" && *all == "" {
+			if outFormat == "" {
+				outFormat = gemini.FormatForFile(*out)
+			}
+			if writer, err = gemini.NewResponseWriter(outFormat, score); err != nil {
+				return err
+			}
+		}
+		config, err := in.config(a, profile)
+		if err != nil {
+			return err
+		}
+		prompt, err := in.prompt(a, args)
+		if err != nil {
+			return err
+		}
+
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		contents := []*genai.Content{
+			genai.NewContentFromText(prompt, genai.RoleUser),
+		}
+
+		prov := gemini.NewProvenance(profile.Model, contents, config)
+		prov.Format = outFormat
+		for _, f := range []struct{ role, file string }{{"system", in.systemFile}, {"user", in.file}} {
+			if f.file != "" && f.file != "-" {
+				if err := prov.AddPromptFile(f.role, f.file); err != nil {
+					return fmt.Errorf("failed to hash prompt file: %w", err)
+				}
+			}
+		}
+
+		a.debugf(1, "generating with %s", profile.Model)
+		response, err := client.Models.GenerateContent(ctx, profile.Model, contents, config)
+		if err != nil {
+			return fmt.Errorf("failed to generate content: %w", err)
+		}
+		prov.RecordResponse(response)
+		a.logReport(response)
+
+		if a.output == outputJSON {
+			if err := printJSON(response); err != nil {
+				return err
+			}
+		}
+
+		index, text, err := gemini.SelectCandidate(response, score)
+		if err != nil {
+			return err
+		}
+		if len(response.Candidates) > 1 {
+			a.debugf(1, "selected candidate %d of %d", index+1, len(response.Candidates))
+		}
+
+		opts := gemini.FileOptions{IfExists: existsMode}
+		switch *all {
+		case "files":
+			written, err := gemini.WriteCandidatesToFiles(response, *out, opts)
+			if err != nil {
+				return fmt.Errorf("failed to write candidates: %w", err)
+			}
+			a.logf("wrote %s", strings.Join(written, ", "))
+		case "sections":
+			written, err := gemini.WriteCandidatesToMarkdown(response, *out, opts)
+			if err != nil {
+				return fmt.Errorf("failed to write candidates: %w", err)
+			}
+			a.logf("wrote %s", written)
+		default:
+			if writer == nil {
+				break
+			}
+			if !*provenance {
+				written, err := gemini.WriteResponseFile(*out, writer, response, prov.Meta(), opts)
+				if err != nil {
+					return fmt.Errorf("failed to write response: %w", err)
+				}
+				a.logf("wrote %s", written)
+				break
+			}
+			if err := a.saveWithProvenance(ctx, *out, writer, response, prov, opts); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case a.output == outputRaw:
+			fmt.Print(text)
+		case a.output == outputText && len(response.Candidates) > 1:
+			fmt.Println(text)
+		case a.output == outputText:
+			gemini.PrintResponse(response)
+		}
+		return nil
+	}
+}
+
+// saveWithProvenance writes the response and its provenance sidecar,
+// including the model version reported by the API when it is available.
+func (a *app) saveWithProvenance(ctx context.Context, out string, writer gemini.ResponseWriter, response *genai.GenerateContentResponse, prov *gemini.Provenance, opts gemini.FileOptions) error {
+	client, err := a.genaiClient(ctx)
+	if err != nil {
+		return err
+	}
+	if err := prov.DescribeModel(ctx, &gemini.GenAIModelGetter{Client: client}); err != nil {
+		a.debugf(1, "provenance without model details: %v", err)
+	}
+
+	written, err := gemini.WriteResponseWithProvenance(out, writer, response, prov, opts)
+	if err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	a.logf("wrote %s and %s", written, gemini.ProvenanceFile(written))
+	return nil
+}
+
+// logReport prints the response report (finish reasons, safety ratings,
+// citations and token usage) to stderr at -v.
+func (a *app) logReport(resp *genai.GenerateContentResponse) {
+	if a.quiet || a.verbosity < 1 || resp == nil {
+		return
+	}
+	if err := gemini.NewResponseReport(resp).Print(os.Stderr); err != nil {
+		a.logf("failed to print response report: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+)
+
+func lineageCommand() *command {
+	return &command{
+		name:    "lineage",
+		summary: "track how prompt versions derive from each other",
+		subcommands: []*command{
+			{name: "list", summary: "list the families with a lineage manifest", setup: lineageListCommand},
+			{name: "tree", args: "<family>", summary: "print the version tree of a family", setup: lineageTreeCommand},
+			{name: "diff", args: "<family> <from-version> <to-version>", summary: "diff two versions of a family", setup: lineageDiffCommand},
+			{name: "add", args: "<family>", summary: "record a version in a family", setup: lineageAddCommand},
+		},
+	}
+}
+
+func (a *app) lineageDir() (string, error) {
+	dir, err := a.promptsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lineage"), nil
+}
+
+func (a *app) loadLineage(family string) (*prompts.Lineage, error) {
+	dir, err := a.lineageDir()
+	if err != nil {
+		return nil, err
+	}
+	filename, err := prompts.LineageFile(dir, family)
+	if err != nil {
+		return nil, err
+	}
+	lineage, err := prompts.LoadLineage(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lineage: %w", err)
+	}
+	return lineage, nil
+}
+
+func lineageListCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(_ context.Context, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unexpected arguments: %v", args)
+		}
+		dir, err := a.lineageDir()
+		if err != nil {
+			return err
+		}
+		families, err := prompts.LineageFamilies(dir)
+		if err != nil {
+			return fmt.Errorf("failed to list lineage manifests: %w", err)
+		}
+
+		if a.output == outputJSON {
+			return printJSON(families)
+		}
+		for _, family := range families {
+			fmt.Println(family)
+		}
+		return nil
+	}
+}
+
+func lineageTreeCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(_ context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a family name")
+		}
+		lineage, err := a.loadLineage(args[0])
+		if err != nil {
+			return err
+		}
+
+		if a.output == outputJSON {
+			return printJSON(lineage)
+		}
+		if err := lineage.WriteTree(os.Stdout); err != nil {
+			return fmt.Errorf("failed to print lineage tree: %w", err)
+		}
+		return nil
+	}
+}
+
+func lineageDiffCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(_ context.Context, args []string) error {
+		if len(args) != 3 {
+			return fmt.Errorf("expected <family> <from-version> <to-version>")
+		}
+		lineage, err := a.loadLineage(args[0])
+		if err != nil {
+			return err
+		}
+		promptsDir, err := a.promptsDir()
+		if err != nil {
+			return err
+		}
+
+		from, ok := lineage.Entry(args[1])
+		if !ok {
+			return fmt.Errorf("version %q not recorded in %s", args[1], lineage.Family)
+		}
+		to, ok := lineage.Entry(args[2])
+		if !ok {
+			return fmt.Errorf("version %q not recorded in %s", args[2], lineage.Family)
+		}
+
+		fromText, err := os.ReadFile(filepath.Join(promptsDir, from.File))
+		if err != nil {
+			return fmt.Errorf("failed to read version %s: %w", from.Version, err)
+		}
+		toText, err := os.ReadFile(filepath.Join(promptsDir, to.File))
+		if err != nil {
+			return fmt.Errorf("failed to read version %s: %w", to.Version, err)
+		}
+
+		fmt.Print(prompts.UnifiedDiff(
+			fmt.Sprintf("%s (%s)", from.File, from.Version),
+			fmt.Sprintf("%s (%s)", to.File, to.Version),
+			string(fromText), string(toText),
+		))
+		return nil
+	}
+}
+
+// lineageAddCommand records a version by hand; the global -model flag names
+// the model that generated it.
+func lineageAddCommand(a *app, fs *flag.FlagSet) runFunc {
+	var entry prompts.LineageEntry
+	fs.StringVar(&entry.Version, "version", "", "version name")
+	fs.StringVar(&entry.File, "file", "", "prompt file relative to the prompts directory")
+	fs.StringVar(&entry.Parent, "parent", "", "version this one was derived from")
+	fs.StringVar(&entry.Description, "description", "", "what changed compared to the parent")
+	fs.StringVar(&entry.MetaPrompt, "meta-prompt", "", "meta-prompt file used to generate this version")
+
+	return func(_ context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a family name")
+		}
+		family := args[0]
+
+		promptsDir, err := a.promptsDir()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(promptsDir, entry.File)); err != nil {
+			return fmt.Errorf("failed to find prompt file: %w", err)
+		}
+
+		lineageDir, err := a.lineageDir()
+		if err != nil {
+			return err
+		}
+		filename, err := prompts.LineageFile(lineageDir, family)
+		if err != nil {
+			return err
+		}
+		lineage := &prompts.Lineage{Family: family}
+		if _, err := os.Stat(filename); err == nil {
+			if lineage, err = a.loadLineage(family); err != nil {
+				return err
+			}
+		}
+
+		entry.Model = a.model
+		entry.Created = time.Now().UTC().Truncate(time.Second)
+		if err := lineage.Add(entry); err != nil {
+			return fmt.Errorf("failed to record version: %w", err)
+		}
+		if err := lineage.Save(filename); err != nil {
+			return fmt.Errorf("failed to save lineage: %w", err)
+		}
+
+		a.logf("recorded %s %s", family, entry.Version)
+		return nil
+	}
+}
@@ -0,0 +1,402 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/cassette"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/project"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"google.golang.org/genai"
+)
+
+// Output formats accepted by -output. raw writes only the model text, with
+// nothing added, so the output can be piped into other commands.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputRaw  = "raw"
+)
+
+// Exit codes beyond the usual 0 for success and 1 for errors.
+const (
+	exitUsage   = 2
+	exitBlocked = 3
+	exitEmpty   = 4
+)
+
+const exitHelp = `exit status:
+  0  success
+  1  error
+  2  invalid usage
+  3  the prompt or the response was blocked
+  4  the model returned no text`
+
+// runFunc executes a command with the positional arguments left after flag parsing.
+type runFunc func(ctx context.Context, args []string) error
+
+// command is a node in the command tree. Leaf commands register their flags
+// in setup and return the function that runs them; group commands only
+// dispatch to their subcommands.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// help is printed at the end of the usage message.
+	help   string
+	hidden bool
+	// rawArgs passes the arguments through without parsing any flags.
+	rawArgs     bool
+	setup       func(a *app, fs *flag.FlagSet) runFunc
+	subcommands []*command
+}
+
+func (c *command) subcommand(name string) *command {
+	for _, sub := range c.subcommands {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// errUsage reports a command line mistake; the usage has already been printed.
+var errUsage = errors.New("invalid usage")
+
+// verbosity counts repeated -v flags.
+type verbosity int
+
+func (v *verbosity) String() string   { return strconv.Itoa(int(*v)) }
+func (v *verbosity) IsBoolFlag() bool { return true }
+
+func (v *verbosity) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if on {
+		*v++
+	}
+	return nil
+}
+
+// app holds the global flags and the resources shared by all commands.
+type app struct {
+	model     string
+	profile   string
+	output    string
+	verbosity verbosity
+	quiet     bool
+
+	root   string
+	client *genai.Client
+	// jobs replaces the Batch API client when set, for tests.
+	jobs gemini.BatchJobClient
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gemini: ")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{output: outputText}
+	err := a.execute(ctx, rootCommand(), []string{"gemini"}, os.Args[1:])
+	stop()
+	if err != nil {
+		if !errors.Is(err, errUsage) {
+			log.Print(err)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, gemini.ErrBlocked):
+		return exitBlocked
+	case errors.Is(err, gemini.ErrEmptyResponse):
+		return exitEmpty
+	}
+	return 1
+}
+
+func rootCommand() *command {
+	return &command{
+		name:    "gemini",
+		summary: "work with Gemini models and the prompt library",
+		help:    exitHelp,
+		subcommands: []*command{
+			{
+				name:    "models",
+				summary: "inspect the available models",
+				subcommands: []*command{
+					{name: "list", summary: "list models and the actions they support", setup: modelsListCommand},
+					{name: "get", args: "[model]", summary: "show the details of a model", setup: modelsGetCommand},
+				},
+			},
+			{name: "generate", args: "[prompt...]", summary: "generate content from a prompt", setup: generateCommand},
+			{name: "chat", summary: "start an interactive chat session", setup: chatCommand},
+			{name: "count-tokens", args: "[text...]", summary: "count the tokens of a prompt", setup: countTokensCommand},
+			{name: "reproduce", args: "<response-file>", summary: "re-run the request that produced a saved response", help: reproduceHelp, setup: reproduceCommand},
+			promptCommand(),
+			batchCommand(),
+			{name: "completion", args: "bash|zsh|fish", summary: "print a shell completion script", setup: completionCommand},
+			{name: "__complete", hidden: true, rawArgs: true, setup: completeCommand},
+		},
+	}
+}
+
+// globalFlags registers the flags every command accepts. They are registered
+// on each flag set with the current values as defaults, so they may appear
+// before or after the command name.
+func (a *app) globalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&a.model, "model", a.model, "model name, overriding the profile")
+	fs.StringVar(&a.profile, "profile", a.profile, "generation profile from "+project.ConfigFile)
+	fs.StringVar(&a.output, "output", a.output, "output format: text, json or raw")
+	fs.Var(&a.verbosity, "v", "verbose output; repeat for more detail")
+	fs.BoolVar(&a.quiet, "q", a.quiet, "only print results and errors")
+}
+
+func (a *app) execute(ctx context.Context, cmd *command, path []string, args []string) error {
+	name := strings.Join(path, " ")
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	a.globalFlags(fs)
+
+	var run runFunc
+	if cmd.setup != nil {
+		run = cmd.setup(a, fs)
+	}
+	fs.Usage = func() { a.usage(fs, cmd, name) }
+
+	if len(cmd.subcommands) > 0 {
+		// Group commands only take global flags before the subcommand name.
+		if err := fs.Parse(args); err != nil {
+			return parseError(err)
+		}
+		if fs.NArg() == 0 {
+			fs.Usage()
+			return errUsage
+		}
+		sub := cmd.subcommand(fs.Arg(0))
+		if sub == nil {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", fs.Arg(0))
+			fs.Usage()
+			return errUsage
+		}
+		return a.execute(ctx, sub, append(path, sub.name), fs.Args()[1:])
+	}
+
+	if cmd.rawArgs {
+		return run(ctx, args)
+	}
+	if err := fs.Parse(args); err != nil {
+		return parseError(err)
+	}
+	switch a.output {
+	case outputText, outputJSON, outputRaw:
+	default:
+		return fmt.Errorf("unknown output format %q, want %s, %s or %s", a.output, outputText, outputJSON, outputRaw)
+	}
+	return run(ctx, fs.Args())
+}
+
+// isSet reports whether the flag was given on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+func parseError(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return errUsage
+}
+
+func (a *app) usage(fs *flag.FlagSet, cmd *command, name string) {
+	out := fs.Output()
+	if len(cmd.subcommands) > 0 {
+		fmt.Fprintf(out, "usage: %s [flags] <command>\n", name)
+	} else {
+		fmt.Fprintf(out, "usage: %s [flags] %s\n", name, cmd.args)
+	}
+	if cmd.summary != "" {
+		fmt.Fprintf(out, "\n%s\n", cmd.summary)
+	}
+	if len(cmd.subcommands) > 0 {
+		fmt.Fprintln(out, "\ncommands:")
+		for _, sub := range cmd.subcommands {
+			if !sub.hidden {
+				fmt.Fprintf(out, "  %-14s %s\n", sub.name, sub.summary)
+			}
+		}
+	}
+	fmt.Fprintln(out, "\nflags:")
+	fs.PrintDefaults()
+	if cmd.help != "" {
+		fmt.Fprintf(out, "\n%s\n", cmd.help)
+	}
+}
+
+// logf prints progress messages unless -q is set.
+func (a *app) logf(format string, args ...any) {
+	if !a.quiet {
+		log.Printf(format, args...)
+	}
+}
+
+// debugf prints messages shown only at the given -v level or above.
+func (a *app) debugf(level int, format string, args ...any) {
+	if !a.quiet && int(a.verbosity) >= level {
+		log.Printf(format, args...)
+	}
+}
+
+// projectRoot resolves the project root once per run.
+func (a *app) projectRoot() (string, error) {
+	if a.root == "" {
+		root, err := project.FindRoot()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve project root: %w", err)
+		}
+		a.root = root
+	}
+	return a.root, nil
+}
+
+func (a *app) promptsDir() (string, error) {
+	root, err := a.projectRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "prompts"), nil
+}
+
+// genaiClient creates the API client on first use. When GEMINI_CASSETTE
+// names a cassette file, requests are recorded to or replayed from it, see
+// package cassette.
+func (a *app) genaiClient(ctx context.Context) (*genai.Client, error) {
+	if a.client == nil {
+		recorder, err := cassette.FromEnv()
+		if err != nil {
+			return nil, err
+		}
+		var opts gemini.ClientOptions
+		if recorder != nil {
+			a.debugf(1, "using cassette %s", os.Getenv(cassette.PathEnv))
+			opts.HTTPClient = recorder.Client()
+			if recorder.Mode() == cassette.Replay {
+				// The key is never sent in replay, so CI needs none.
+				opts.APIKey = cassette.ReplayAPIKey
+			}
+		}
+		client, err := gemini.NewGenAIClientWithOptions(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gemini client: %w", err)
+		}
+		a.client = client
+	}
+	return a.client, nil
+}
+
+// profiles returns the built-in profiles merged with those of the project
+// config file, if the project root can be found.
+func (a *app) profiles() (gemini.Profiles, error) {
+	root, err := a.projectRoot()
+	if err != nil {
+		a.debugf(1, "using built-in profiles: %v", err)
+		return gemini.DefaultProfiles(), nil
+	}
+	return gemini.LoadProfiles(filepath.Join(root, project.ConfigFile))
+}
+
+// settings returns the selected profile with -model applied. fallback names
+// the profile used when -profile is not given.
+func (a *app) settings(fallback string) (gemini.Profile, error) {
+	profiles, err := a.profiles()
+	if err != nil {
+		return gemini.Profile{}, err
+	}
+
+	name := a.profile
+	if name == "" {
+		name = fallback
+	}
+	profile, err := profiles.Get(name)
+	if err != nil {
+		return gemini.Profile{}, err
+	}
+
+	if a.model != "" {
+		profile.Model = a.model
+	}
+	if profile.Model == "" {
+		profile.Model = gemini.DefaultModel
+	}
+	if profile.SystemInstructionFile != "" {
+		if err := a.readSystemInstruction(&profile); err != nil {
+			return gemini.Profile{}, err
+		}
+	}
+	a.debugf(2, "profile %s: %+v", name, profile)
+	return profile, nil
+}
+
+// readSystemInstruction puts the text of the profile's system instruction
+// file in front of its inline system instruction. Outside a project there
+// are no prompt files, and only the inline text is used.
+func (a *app) readSystemInstruction(profile *gemini.Profile) error {
+	dir, err := a.promptsDir()
+	if err != nil {
+		a.debugf(1, "system instruction file %s unavailable: %v", profile.SystemInstructionFile, err)
+		profile.SystemInstructionFile = ""
+		return nil
+	}
+	text, err := a.renderer().RenderFile(filepath.Join(dir, filepath.FromSlash(profile.SystemInstructionFile)), nil)
+	if err != nil {
+		return fmt.Errorf("failed to read system instruction: %w", err)
+	}
+	if profile.SystemInstruction != "" {
+		text += "\n\n" + profile.SystemInstruction
+	}
+	profile.SystemInstruction = text
+	profile.SystemInstructionFile = ""
+	return nil
+}
+
+// renderer renders prompt files with the partials of the project, if the
+// project root can be found.
+func (a *app) renderer() *prompts.Renderer {
+	renderer := &prompts.Renderer{IgnoreUnknown: true}
+	if dir, err := a.promptsDir(); err == nil {
+		renderer.PartialsDir = filepath.Join(dir, "partials")
+	} else {
+		a.debugf(1, "prompt partials unavailable: %v", err)
+	}
+	return renderer
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
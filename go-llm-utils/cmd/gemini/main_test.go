@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/batch"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/cassette"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/fakeserver"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/geminitest"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+// The replay tests run the CLI against recorded API exchanges in
+// testdata/cassettes. To record the cassettes again, run them with
+// GEMINI_CASSETTE_MODE=record: against the real API when GEMINI_API_KEY is
+// set, else against the fake server. The other tests run the CLI against the
+// fake server directly. They change the environment and os.Stdout, so they
+// cannot run in parallel.
+
+// recording reports whether the replay tests record their cassettes.
+func recording() bool {
+	return os.Getenv(cassette.ModeEnv) == "record"
+}
+
+// useCassette replays or records the named cassette.
+func useCassette(t *testing.T, name string) {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("testdata", "cassettes", name+".json"))
+	require.NoError(t, err)
+	t.Setenv(cassette.PathEnv, path)
+	if recording() && os.Getenv("GEMINI_API_KEY") == "" {
+		s := useFakeServer(t)
+		t.Setenv(cassette.PathEnv, path)
+		t.Logf("recording %s against the fake server at %s", name, s.URL)
+	}
+}
+
+// useFakeServer sends the API requests to a fake server.
+func useFakeServer(t *testing.T) *fakeserver.Server {
+	t.Helper()
+	s := fakeserver.New()
+	t.Cleanup(s.Close)
+	t.Setenv(cassette.PathEnv, "")
+	t.Setenv(fakeserver.BaseURLEnv, s.URL)
+	t.Setenv("GEMINI_API_KEY", s.APIKey)
+	return s
+}
+
+// runGemini runs the CLI in a project root that has only the system
+// instruction of the default profile and returns what it printed to stdout.
+func runGemini(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	return runApp(t, &app{output: outputText}, args...)
+}
+
+// runApp is runGemini for an app set up by the test.
+func runApp(t *testing.T, a *app, args ...string) (string, error) {
+	t.Helper()
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "prompts", "system"), 0755))
+	system, err := os.ReadFile(filepath.Join("..", "..", "..", "prompts", filepath.FromSlash(gemini.DefaultSystemInstructionFile)))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "prompts", filepath.FromSlash(gemini.DefaultSystemInstructionFile)), system, 0644))
+	t.Setenv(project.RootEnv, root)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	printed := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		printed <- string(data)
+	}()
+
+	err = a.execute(context.Background(), rootCommand(), []string{"gemini"}, append([]string{"-q"}, args...))
+	w.Close()
+	return <-printed, err
+}
+
+func TestGenerateReplay(t *testing.T) {
+	useCassette(t, "generate")
+	out := filepath.Join(t.TempDir(), "response.md")
+
+	printed, err := runGemini(t, "generate", "-output", "raw", "-out", out, "Say hello in one word.")
+	require.NoError(t, err)
+	assert.Equal(t, "echo: Say hello in one word.", printed)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "echo: Say hello in one word.")
+
+	prov, err := gemini.LoadProvenance(out)
+	require.NoError(t, err)
+	assert.Equal(t, "models/gemini-2.0-flash", prov.Model)
+	require.NotNil(t, prov.ModelInfo, "the model was described from the cassette")
+	assert.Equal(t, "Gemini 2.0 Flash", prov.ModelInfo.DisplayName)
+}
+
+func TestGenerateReplayUnrecordedRequest(t *testing.T) {
+	if recording() {
+		t.Skip("recording would replace the generate cassette")
+	}
+	useCassette(t, "generate")
+	_, err := runGemini(t, "generate", "-output", "raw", "A prompt that was never recorded.")
+	assert.ErrorContains(t, err, "no recorded interaction for POST")
+}
+
+func TestCountTokensReplay(t *testing.T) {
+	useCassette(t, "count-tokens")
+
+	printed, err := runGemini(t, "-output", "json", "count-tokens", "-system", "Be brief.", "one two three four")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"totalTokens": 6}`, printed)
+}
+
+func TestModelsListReplay(t *testing.T) {
+	useCassette(t, "models-list")
+
+	printed, err := runGemini(t, "models", "list", "-action", "embedContent")
+	require.NoError(t, err)
+	assert.Equal(t, "models/text-embedding-004                     embedContent\nmodels/gemini-embedding-001                   embedContent\n", printed)
+}
+
+func TestCountTokensFakeServer(t *testing.T) {
+	s := useFakeServer(t)
+
+	printed, err := runGemini(t, "count-tokens", "-system", "Be brief.", "one two three four")
+	require.NoError(t, err)
+	assert.Equal(t, "6\n", printed)
+	require.Len(t, s.Requests(fakeserver.CountTokens), 1)
+	assert.Equal(t, "models/gemini-2.0-flash", s.Requests(fakeserver.CountTokens)[0].Model)
+
+	printed, err = runGemini(t, "count-tokens", "one two three four")
+	require.NoError(t, err)
+	assert.Equal(t, "22\n", printed, "the default system instruction counts too")
+}
+
+func TestGenerateFakeServerErrors(t *testing.T) {
+	s := useFakeServer(t)
+	s.Script(fakeserver.GenerateContent, fakeserver.RateLimited(), fakeserver.Blocked(genai.HarmCategoryHarassment))
+
+	_, err := runGemini(t, "generate", "first")
+	assert.ErrorContains(t, err, "Resource has been exhausted")
+
+	_, err = runGemini(t, "generate", "second")
+	require.ErrorIs(t, err, gemini.ErrBlocked)
+	assert.Equal(t, exitBlocked, exitCode(err))
+
+	printed, err := runGemini(t, "generate", "-output", "raw", "third")
+	require.NoError(t, err)
+	assert.Equal(t, "echo: third", printed)
+}
+
+func TestGenerateAllCandidatesIfExists(t *testing.T) {
+	useFakeServer(t)
+	out := filepath.Join(t.TempDir(), "response.md")
+
+	_, err := runGemini(t, "generate", "-all", "files", "-out", out, "first")
+	require.NoError(t, err)
+	_, err = runGemini(t, "generate", "-all", "files", "-out", out, "-if-exists", "fail", "second")
+	require.ErrorIs(t, err, fs.ErrExist)
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(out), "response-1.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "echo: first", string(data))
+
+	_, err = runGemini(t, "generate", "-all", "sections", "-out", out, "-provenance", "third")
+	assert.ErrorContains(t, err, "-all writes no provenance sidecar")
+}
+
+func TestBatchSubmitLocalJobs(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "requests.jsonl")
+	require.NoError(t, os.WriteFile(input, []byte(`{"id": "a", "prompt": "one"}`+"\n"+`{"id": "b", "prompt": "two"}`+"\n"), 0644))
+	manifest := filepath.Join(dir, "requests.job.json")
+
+	a := &app{output: outputText, jobs: &batch.LocalJobs{Generator: &geminitest.Generator{Fallback: geminitest.Echo}}}
+	printed, err := runApp(t, a, "batch", "submit", input)
+	require.NoError(t, err)
+	assert.Equal(t, "batches/local-1\tmodels/gemini-2.0-flash\tJOB_STATE_PENDING\t2 requests\n", printed)
+	require.FileExists(t, manifest)
+
+	printed, err = runApp(t, a, "batch", "status", manifest)
+	require.NoError(t, err)
+	assert.Contains(t, printed, "JOB_STATE_RUNNING")
+	printed, err = runApp(t, a, "batch", "status", manifest)
+	require.NoError(t, err)
+	assert.Contains(t, printed, "JOB_STATE_SUCCEEDED")
+
+	_, err = runApp(t, a, "batch", "download", manifest)
+	require.NoError(t, err)
+	done, err := batch.Completed(filepath.Join(dir, "requests.results.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true, "b": true}, done)
+
+	printed, err = runApp(t, a, "batch", "submit", input)
+	require.NoError(t, err)
+	assert.Empty(t, printed, "requests with results are not submitted again")
+}
+
+func TestPromptCompareFakeServer(t *testing.T) {
+	s := useFakeServer(t)
+	dir := t.TempDir()
+	for name, text := range map[string]string{"terse.md": "Be terse.", "chatty.md": "Be chatty."} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(text), 0644))
+	}
+	out := filepath.Join(dir, "report.html")
+
+	printed, err := runGemini(t, "prompt", "compare", "-input", "one two", "-input", "three",
+		"-models", "models/gemini-2.0-flash,models/gemini-2.5-pro", "-out", out,
+		filepath.Join(dir, "terse.md"), filepath.Join(dir, "chatty.md"))
+	require.NoError(t, err)
+	assert.Len(t, s.Requests(fakeserver.GenerateContent), 8)
+	assert.Contains(t, printed, "| | terse · gemini-2.0-flash | terse · gemini-2.5-pro | chatty · gemini-2.0-flash | chatty · gemini-2.5-pro |")
+	assert.Contains(t, printed, "| Output | echo: one two | echo: one two | echo: one two | echo: one two |")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<div class="text">echo: three</div>`)
+}
+
+func TestPromptStabilityFakeServer(t *testing.T) {
+	s := useFakeServer(t)
+	prompt := filepath.Join(t.TempDir(), "terse.md")
+	require.NoError(t, os.WriteFile(prompt, []byte("Be terse."), 0644))
+
+	printed, err := runGemini(t, "prompt", "stability", "-runs", "3", "-temperatures", "0,1", "-input", "hello", prompt)
+	require.NoError(t, err, "the fake server always echoes")
+	assert.Contains(t, printed, "3 runs per input with seeds 5-7")
+	assert.Contains(t, printed, "terse | 100% | 0.00 | 1.00 | 0 of 2 |")
+
+	requests := s.Requests(fakeserver.GenerateContent)
+	require.Len(t, requests, 6)
+	assert.Contains(t, string(requests[0].Body), `"seed":`)
+}
@@ -0,0 +1,86 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+)
+
+func modelsListCommand(a *app, fs *flag.FlagSet) runFunc {
+	action := fs.String("action", "", "only list models that support this action, e.g. generateContent or embedContent")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unexpected arguments: %v", args)
+		}
+
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		models, err := gemini.ListModels(ctx, &gemini.GenAIModelLister{Client: client})
+		if err != nil {
+			return fmt.Errorf("failed to list models: %w", err)
+		}
+
+		items := models.Items
+		if *action != "" {
+			items = gemini.FilterModelsByAction(items, *action)
+		}
+
+		if a.output == outputJSON {
+			return printJSON(items)
+		}
+		for _, model := range items {
+			fmt.Printf("%-45s %s\n", model.Name, strings.Join(model.SupportedActions, ","))
+		}
+		return nil
+	}
+}
+
+func modelsGetCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(ctx context.Context, args []string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("expected at most one model name, got %v", args)
+		}
+
+		modelName := a.model
+		if len(args) == 1 {
+			modelName = args[0]
+		}
+		if modelName == "" {
+			profile, err := a.settings(gemini.DefaultProfile)
+			if err != nil {
+				return err
+			}
+			modelName = profile.Model
+		}
+
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		model, err := gemini.ModelsGet(ctx, &gemini.GenAIModelGetter{Client: client}, modelName)
+		if err != nil {
+			return fmt.Errorf("failed to get model: %w", err)
+		}
+
+		if a.output == outputJSON {
+			return printJSON(model)
+		}
+		fmt.Printf("Name:               %s\n", model.Name)
+		fmt.Printf("Display Name:       %s\n", model.DisplayName)
+		fmt.Printf("Description:        %s\n", model.Description)
+		fmt.Printf("Version:            %s\n", model.Version)
+		fmt.Printf("Input Token Limit:  %d\n", model.InputTokenLimit)
+		fmt.Printf("Output Token Limit: %d\n", model.OutputTokenLimit)
+		fmt.Printf("Supported Actions:  %s\n", strings.Join(model.SupportedActions, ", "))
+		return nil
+	}
+}
@@ -0,0 +1,167 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/search"
+)
+
+const (
+	embeddingModel = "models/gemini-embedding-001"
+	indexFile      = ".prompt-index.json"
+	resultLimit    = 10
+)
+
+func promptCommand() *command {
+	return &command{
+		name:    "prompt",
+		summary: "generate, edit and browse the prompt library",
+		subcommands: []*command{
+			{name: "generate", summary: "generate a new prompt with the meta-prompt", setup: promptGenerateCommand},
+			{name: "edit", args: "<prompt-file>", summary: "revise a prompt with the edit meta-prompt", setup: promptEditCommand},
+			{name: "list", summary: "list the prompts in the library", setup: promptListCommand},
+			{name: "show", args: "<id> [version]", summary: "print a prompt", setup: promptShowCommand},
+			{name: "search", args: "<query>", summary: "search the prompt library", setup: promptSearchCommand},
+			{name: "test", args: "[suite.yaml...]", summary: "run regression test suites against a prompt", help: promptTestHelp, setup: promptTestCommand},
+			{name: "judge", args: "<suite.yaml> [version [version]]", summary: "score or compare prompt versions with a judge model", help: promptJudgeHelp, setup: promptJudgeCommand},
+			{name: "compare", args: "<prompt[@version]|file.md>...", summary: "compare prompts and models side by side", help: promptCompareHelp, setup: promptCompareCommand},
+			{name: "stability", args: "<prompt[@version]|file.md>...", summary: "measure how much outputs vary across seeds and temperatures", help: promptStabilityHelp, setup: promptStabilityCommand},
+			lineageCommand(),
+		},
+	}
+}
+
+func (a *app) library() (*prompts.Library, error) {
+	dir, err := a.promptsDir()
+	if err != nil {
+		return nil, err
+	}
+	lib, err := prompts.LoadLibrary(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt library: %w", err)
+	}
+	for _, problem := range lib.Problems() {
+		a.logf("warning: %v", problem)
+	}
+	return lib, nil
+}
+
+func promptListCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(_ context.Context, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unexpected arguments: %v", args)
+		}
+		lib, err := a.library()
+		if err != nil {
+			return err
+		}
+
+		if a.output == outputJSON {
+			return printJSON(lib.All())
+		}
+		for _, id := range lib.IDs() {
+			for _, p := range lib.Versions(id) {
+				fmt.Printf("%-60s %-8s %-20s prompts/%s\n", p.ID, p.Version, p.TargetModel, p.Path)
+			}
+		}
+		return nil
+	}
+}
+
+func promptShowCommand(a *app, _ *flag.FlagSet) runFunc {
+	return func(_ context.Context, args []string) error {
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("expected <id> [version], got %v", args)
+		}
+		var version string
+		if len(args) == 2 {
+			version = args[1]
+		}
+
+		lib, err := a.library()
+		if err != nil {
+			return err
+		}
+		p, err := lib.Get(args[0], version)
+		if err != nil {
+			return fmt.Errorf("failed to get prompt: %w", err)
+		}
+
+		if a.output == outputJSON {
+			return printJSON(p)
+		}
+		fmt.Print(p.Body)
+		return nil
+	}
+}
+
+func promptSearchCommand(a *app, fs *flag.FlagSet) runFunc {
+	limit := fs.Int("limit", resultLimit, "maximum number of results")
+	keywordOnly := fs.Bool("keyword-only", false, "skip embeddings and rank by keywords only")
+
+	return func(ctx context.Context, args []string) error {
+		query := strings.Join(args, " ")
+		if query == "" {
+			return fmt.Errorf("search query cannot be empty")
+		}
+
+		projectRoot, err := a.projectRoot()
+		if err != nil {
+			return err
+		}
+
+		var embedder search.Embedder
+		if !*keywordOnly {
+			client, err := a.genaiClient(ctx)
+			if err != nil {
+				return err
+			}
+			embedder = &gemini.TextEmbedder{
+				Embedder: &gemini.GenAIContentEmbedder{Client: client},
+				Model:    embeddingModel,
+			}
+		}
+
+		indexPath := filepath.Join(projectRoot, indexFile)
+		index, err := search.Load(indexPath)
+		if err != nil {
+			return fmt.Errorf("failed to load search index: %w", err)
+		}
+
+		stats, err := index.Sync(ctx, filepath.Join(projectRoot, "prompts"), embedder)
+		if err != nil {
+			return fmt.Errorf("failed to update search index: %w", err)
+		}
+		if stats.Added+stats.Updated+stats.Removed+stats.Embedded > 0 {
+			a.debugf(1, "index updated: %d added, %d updated, %d removed, %d embedded",
+				stats.Added, stats.Updated, stats.Removed, stats.Embedded)
+			if err := index.Save(indexPath); err != nil {
+				return fmt.Errorf("failed to save search index: %w", err)
+			}
+		}
+
+		results, err := index.Search(ctx, query, embedder, *limit)
+		if err != nil {
+			return fmt.Errorf("failed to search prompts: %w", err)
+		}
+
+		if a.output == outputJSON {
+			return printJSON(results)
+		}
+		if len(results) == 0 {
+			fmt.Println("No matching prompts.")
+			return nil
+		}
+		for i, r := range results {
+			fmt.Printf("%2d. %.3f  prompts/%s  (%s)\n", i+1, r.Score, r.Path, r.Title)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,155 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/batch"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/eval"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+)
+
+const promptCompareHelp = `Sends every input with every prompt, used as the system instruction, to
+every model and reports the outputs side by side with their latency and
+token usage. A prompt is a library ID with an optional @version, an ID
+pattern such as "task-specific/trading-api-assistant/*" for all the model
+variants in a directory, or a markdown file.
+
+Inputs come from -input, repeatable, or from the cases of a test suite with
+-suite. The report is printed as markdown; -out also writes it to a file as
+markdown, HTML or JSON, chosen by the extension.`
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// inputFlags holds the flags of the commands that send a list of inputs
+// with each of several prompts.
+type inputFlags struct {
+	inputs stringList
+	suite  string
+	vars   prompts.Vars
+}
+
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	fs.Var(&f.inputs, "input", "input to send (repeatable)")
+	fs.StringVar(&f.suite, "suite", "", "test suite whose cases are the inputs")
+	f.vars = prompts.Vars{}
+	fs.Var(f.vars, "var", "prompt template variable as name=value (repeatable)")
+}
+
+// load returns the cases of -suite followed by the -input values.
+func (f *inputFlags) load() ([]eval.Input, error) {
+	var in []eval.Input
+	if f.suite != "" {
+		suite, err := eval.LoadSuite(f.suite)
+		if err != nil {
+			return nil, err
+		}
+		in = suite.Inputs()
+	}
+	for i, text := range f.inputs {
+		in = append(in, eval.Input{Name: fmt.Sprintf("input-%d", i+1), Text: text, Vars: f.vars})
+	}
+	if len(in) == 0 {
+		return nil, fmt.Errorf("no inputs; use -input or -suite")
+	}
+	return in, nil
+}
+
+// variants resolves the prompt arguments against the library.
+func (a *app) variants(args []string) ([]eval.Variant, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected at least one prompt")
+	}
+	lib, err := a.library()
+	if err != nil {
+		return nil, err
+	}
+	return eval.ResolveVariants(lib, args)
+}
+
+func promptCompareCommand(a *app, fs *flag.FlagSet) runFunc {
+	var inputs inputFlags
+	inputs.register(fs)
+	models := fs.String("models", "", "comma-separated models to compare (default the profile's model)")
+	out := fs.String("out", "", "also write the report to this .md, .html or .json file")
+	concurrency := fs.Int("concurrency", batch.DefaultConcurrency, "maximum number of requests in flight")
+
+	return func(ctx context.Context, args []string) error {
+		variants, err := a.variants(args)
+		if err != nil {
+			return err
+		}
+		in, err := inputs.load()
+		if err != nil {
+			return err
+		}
+		var modelList []string
+		for _, m := range strings.Split(*models, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				modelList = append(modelList, m)
+			}
+		}
+
+		profiles, err := a.profiles()
+		if err != nil {
+			return err
+		}
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+		comparer := &eval.Comparer{
+			Settings:    a.batchSettings(profiles),
+			Generator:   &gemini.GenAIContentGenerator{Client: client},
+			Concurrency: *concurrency,
+		}
+
+		a.logf("comparing %d prompts on %d inputs", len(variants), len(in))
+		matrix, err := comparer.Run(ctx, variants, modelList, in)
+		if err != nil {
+			return err
+		}
+
+		if *out != "" {
+			var buf bytes.Buffer
+			switch gemini.FormatForFile(*out) {
+			case gemini.FormatHTML:
+				err = matrix.WriteHTML(&buf)
+			case gemini.FormatJSON:
+				enc := json.NewEncoder(&buf)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(matrix)
+			default:
+				err = matrix.WriteMarkdown(&buf)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to render the report: %w", err)
+			}
+			written, err := gemini.WriteFile(*out, buf.Bytes(), gemini.FileOptions{})
+			if err != nil {
+				return err
+			}
+			a.logf("report written to %s", written)
+		}
+
+		if a.output == outputJSON {
+			return printJSON(matrix)
+		}
+		return matrix.WriteMarkdown(os.Stdout)
+	}
+}
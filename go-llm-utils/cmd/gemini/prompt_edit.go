@@ -0,0 +1,212 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"google.golang.org/genai"
+)
+
+const editMetaPrompt = "system/meta-prompt-for-edits.md"
+
+func promptEditCommand(a *app, fs *flag.FlagSet) runFunc {
+	change := fs.String("change", "", "description of the change to make")
+	changeFile := fs.String("change-file", "", "file containing the change description")
+	family := fs.String("family", "", "lineage family to record the new version in (optional)")
+	saveReasoning := fs.Bool("save-reasoning", false, "also write the model's reasoning next to the new version")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected the prompt file to edit, relative to the prompts directory")
+		}
+		if *changeFile != "" {
+			data, err := os.ReadFile(*changeFile)
+			if err != nil {
+				return fmt.Errorf("failed to read change description: %w", err)
+			}
+			*change = string(data)
+		}
+		if strings.TrimSpace(*change) == "" {
+			return fmt.Errorf("a change description is required (-change or -change-file)")
+		}
+
+		profile, err := a.settings(gemini.PromptEngineeringProfile)
+		if err != nil {
+			return err
+		}
+		promptsDir, err := a.promptsDir()
+		if err != nil {
+			return err
+		}
+
+		systemPrompt, err := a.renderer().RenderFile(filepath.Join(promptsDir, editMetaPrompt), nil)
+		if err != nil {
+			return fmt.Errorf("failed to read edit meta-prompt: %w", err)
+		}
+
+		originalFile := filepath.Join(promptsDir, args[0])
+		original, err := gemini.ReadTextFromFile(originalFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		meta, body, hasFrontMatter, err := prompts.ParseFrontMatter([]byte(original))
+		if err != nil {
+			return fmt.Errorf("failed to parse prompt file: %w", err)
+		}
+
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		profile.SystemInstruction = systemPrompt
+		userPrompt := fmt.Sprintf("Current Prompt:\n%s\n\nChange Description:\n%s", strings.TrimSpace(body), strings.TrimSpace(*change))
+		contents := []*genai.Content{
+			genai.NewContentFromText(userPrompt, genai.RoleUser),
+		}
+
+		a.debugf(1, "editing with %s", profile.Model)
+		response, err := client.Models.GenerateContent(ctx, profile.Model, contents, profile.GenerateContentConfig())
+		if err != nil {
+			return fmt.Errorf("failed to generate content: %w", err)
+		}
+		a.logReport(response)
+
+		parsed, err := gemini.ParseResponse(response)
+		if err != nil {
+			return fmt.Errorf("failed to read model response: %w", err)
+		}
+		if len(parsed.Incomplete) > 0 {
+			return fmt.Errorf("model response is cut off inside <%s>", parsed.Incomplete[0])
+		}
+		if parsed.Reasoning() != "" {
+			a.debugf(1, "model reasoning:\n%s", parsed.Reasoning())
+		}
+		if parsed.Answer == "" {
+			return fmt.Errorf("model response contains no revised prompt")
+		}
+		revised := parsed.Answer + "\n"
+
+		revisedFile, err := prompts.NextVersionFile(originalFile)
+		if err != nil {
+			return fmt.Errorf("failed to choose a file name for the new version: %w", err)
+		}
+		if hasFrontMatter {
+			meta.Version = prompts.FileVersion(revisedFile)
+			revised, err = prompts.FormatFrontMatter(meta, revised)
+			if err != nil {
+				return fmt.Errorf("failed to carry over front matter: %w", err)
+			}
+		}
+		// Never replace an existing file: NextVersionFile picked a free name,
+		// so one appearing meanwhile belongs to someone else.
+		newFile := gemini.FileOptions{IfExists: gemini.Fail}
+		if _, err := gemini.WriteFile(revisedFile, []byte(revised), newFile); err != nil {
+			return fmt.Errorf("failed to write revised prompt: %w", err)
+		}
+
+		revisedRel, err := filepath.Rel(promptsDir, revisedFile)
+		if err != nil {
+			return fmt.Errorf("failed to resolve revised prompt path: %w", err)
+		}
+		revisedRel = filepath.ToSlash(revisedRel)
+		originalRel := filepath.ToSlash(filepath.Clean(args[0]))
+
+		diff := prompts.UnifiedDiff(originalRel, revisedRel, original, revised)
+		diffFile := strings.TrimSuffix(revisedFile, filepath.Ext(revisedFile)) + ".diff"
+		if _, err := gemini.WriteFile(diffFile, []byte(diff), newFile); err != nil {
+			return fmt.Errorf("failed to write diff: %w", err)
+		}
+
+		if *saveReasoning && parsed.Reasoning() != "" {
+			reasoningFile := strings.TrimSuffix(revisedFile, filepath.Ext(revisedFile)) + ".reasoning.txt"
+			if _, err := gemini.WriteFile(reasoningFile, []byte(parsed.Reasoning()+"\n"), newFile); err != nil {
+				return fmt.Errorf("failed to write reasoning: %w", err)
+			}
+		}
+
+		if *family != "" {
+			err := recordEdit(promptsDir, *family, originalRel, revisedRel, strings.TrimSpace(*change), profile.Model)
+			if err != nil {
+				return err
+			}
+		}
+
+		if a.output == outputJSON {
+			return printJSON(map[string]string{"file": revisedFile, "diff_file": diffFile, "diff": diff})
+		}
+		fmt.Print(diff)
+		a.logf("wrote %s and %s", revisedFile, diffFile)
+		return nil
+	}
+}
+
+// recordEdit adds the revised prompt to the family manifest as a child of
+// the original, recording the original as a root first if it is not tracked yet.
+func recordEdit(promptsDir, family, originalRel, revisedRel, change, model string) error {
+	lineageDir := filepath.Join(promptsDir, "lineage")
+	filename, err := prompts.LineageFile(lineageDir, family)
+	if err != nil {
+		return err
+	}
+
+	lineage := &prompts.Lineage{Family: family}
+	if _, err := os.Stat(filename); err == nil {
+		lineage, err = prompts.LoadLineage(filename)
+		if err != nil {
+			return fmt.Errorf("failed to load lineage: %w", err)
+		}
+	}
+
+	var parent string
+	for _, e := range lineage.Versions {
+		if e.File == originalRel {
+			parent = e.Version
+		}
+	}
+	if parent == "" {
+		parent = uniqueVersion(lineage, originalRel)
+		if err := lineage.Add(prompts.LineageEntry{Version: parent, File: originalRel}); err != nil {
+			return fmt.Errorf("failed to record original version: %w", err)
+		}
+	}
+
+	err = lineage.Add(prompts.LineageEntry{
+		Version:     uniqueVersion(lineage, revisedRel),
+		File:        revisedRel,
+		Parent:      parent,
+		Description: change,
+		MetaPrompt:  editMetaPrompt,
+		Model:       model,
+		Created:     time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record revised version: %w", err)
+	}
+	if err := lineage.Save(filename); err != nil {
+		return fmt.Errorf("failed to save lineage: %w", err)
+	}
+	return nil
+}
+
+func versionName(filename string) string {
+	return strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+}
+
+// uniqueVersion names a version after its file, falling back to the full
+// relative path when a file of the same name elsewhere is already recorded.
+func uniqueVersion(lineage *prompts.Lineage, rel string) string {
+	version := versionName(rel)
+	if _, taken := lineage.Entry(version); taken {
+		version = strings.TrimSuffix(rel, filepath.Ext(rel))
+	}
+	return version
+}
@@ -0,0 +1,174 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"google.golang.org/genai"
+)
+
+const (
+	metaPromptFile = "system/meta-prompt.md"
+	taskFile       = "user/prompt-generator.md"
+)
+
+func promptGenerateCommand(a *app, flags *flag.FlagSet) runFunc {
+	vars := prompts.Vars{}
+	flags.Var(vars, "var", "prompt template variable as name=value (repeatable)")
+	task := flags.String("task", "", "task description given inline")
+	taskPath := flags.String("task-file", "", `task description file, or "-" for stdin (default stdin if piped, else prompts/`+taskFile+`)`)
+	metaPrompt := flags.String("meta-prompt", metaPromptFile, "meta-prompt file, relative to the prompts directory")
+	name := flags.String("name", "", "prompt family name; the result is written to prompts/task-specific/<name>/vN.md")
+	out := flags.String("out", "", "explicit output file instead of a new version under prompts/task-specific/<name>/")
+	force := flags.Bool("force", false, "allow -out to overwrite an existing file")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unexpected arguments: %v", args)
+		}
+		if *task != "" && *taskPath != "" {
+			return fmt.Errorf("-task and -task-file are mutually exclusive")
+		}
+		if *task == "" && *taskPath == "" && stdinPiped() {
+			*taskPath = "-"
+		}
+
+		profile, err := a.settings(gemini.PromptEngineeringProfile)
+		if err != nil {
+			return err
+		}
+		projectRoot, err := a.projectRoot()
+		if err != nil {
+			return err
+		}
+		renderer := a.renderer()
+
+		systemPromptFile := filepath.Join(projectRoot, "prompts", filepath.FromSlash(*metaPrompt))
+		systemPrompt, err := renderer.RenderFile(systemPromptFile, vars)
+		if err != nil {
+			return fmt.Errorf("failed to read meta-prompt: %w", err)
+		}
+
+		var userPrompt string
+		switch {
+		case *task != "":
+			userPrompt, err = renderer.RenderText("-task", []byte(*task), vars)
+		case *taskPath == "-":
+			var data []byte
+			data, err = io.ReadAll(os.Stdin)
+			if err == nil {
+				userPrompt, err = renderer.RenderText("stdin", data, vars)
+			}
+		case *taskPath != "":
+			userPrompt, err = renderer.RenderFile(*taskPath, vars)
+		default:
+			userPrompt, err = renderer.RenderFile(filepath.Join(projectRoot, "prompts", taskFile), vars)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read task description: %w", err)
+		}
+		if userPrompt == "" {
+			return fmt.Errorf("task description is empty")
+		}
+
+		taskSource := *taskPath
+		if *task == "" && taskSource == "" {
+			taskSource = taskFile
+		}
+		responseFile, err := outputFile(projectRoot, *out, *name, taskSource, *force)
+		if err != nil {
+			return fmt.Errorf("failed to choose output file: %w", err)
+		}
+
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		profile.SystemInstruction = systemPrompt
+		contents := []*genai.Content{
+			genai.NewContentFromText(userPrompt, genai.RoleUser),
+		}
+
+		a.debugf(1, "generating with %s", profile.Model)
+		response, err := client.Models.GenerateContent(ctx, profile.Model, contents, profile.GenerateContentConfig())
+		if err != nil {
+			return fmt.Errorf("failed to generate content: %w", err)
+		}
+		a.logReport(response)
+
+		parsed, err := gemini.ParseResponse(response)
+		if err != nil {
+			return fmt.Errorf("failed to read model response: %w", err)
+		}
+		if len(parsed.Incomplete) > 0 {
+			return fmt.Errorf("model response is cut off inside <%s>", parsed.Incomplete[0])
+		}
+		if parsed.Reasoning() != "" {
+			a.debugf(1, "model reasoning:\n%s", parsed.Reasoning())
+		}
+		if parsed.Answer == "" {
+			return fmt.Errorf("model response contains no prompt")
+		}
+
+		if err := os.MkdirAll(filepath.Dir(responseFile), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		// Refuse to overwrite at write time as well, in case the file
+		// appeared while the model was generating.
+		writeOpts := gemini.FileOptions{IfExists: gemini.Fail}
+		if *force {
+			writeOpts.IfExists = gemini.Overwrite
+		}
+		text := strings.ReplaceAll(parsed.Answer, "\\n", "\n")
+		if _, err := gemini.WriteFile(responseFile, []byte(text), writeOpts); err != nil {
+			return fmt.Errorf("failed to write response to markdown file: %w", err)
+		}
+
+		switch a.output {
+		case outputJSON:
+			return printJSON(map[string]string{"file": responseFile, "prompt": parsed.Answer})
+		case outputRaw:
+			fmt.Print(parsed.Answer)
+		default:
+			fmt.Println(parsed.Answer)
+		}
+		a.logf("wrote %s", responseFile)
+		return nil
+	}
+}
+
+// outputFile picks where the generated prompt goes. An explicit path is used
+// as is, refusing to overwrite unless force is set; otherwise the result
+// becomes the next vN.md under prompts/task-specific/<name>/, where name
+// defaults to the task file name.
+func outputFile(projectRoot, out, name, taskSource string, force bool) (string, error) {
+	if out != "" {
+		if _, err := os.Stat(out); err == nil && !force {
+			return "", fmt.Errorf("%s already exists, use -force to overwrite it", out)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		return out, nil
+	}
+
+	if name == "" {
+		if taskSource == "" || taskSource == "-" {
+			return "", fmt.Errorf("-name is required when the task is given inline or on stdin")
+		}
+		name = strings.TrimSuffix(filepath.Base(taskSource), filepath.Ext(taskSource))
+	}
+
+	dir := filepath.Join(projectRoot, "prompts", "task-specific", name)
+	return prompts.NextVersionFile(filepath.Join(dir, "v1.md"))
+}
@@ -0,0 +1,102 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/eval"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+)
+
+const promptJudgeHelp = `Runs the cases of a suite and has a judge model score every output against
+a rubric. With two versions, both are run and the judge also compares their
+outputs case by case; each comparison is asked twice with the outputs
+swapped, and verdicts that change with the order count as ties.
+
+A rubric is a YAML file in prompts/rubrics/:
+  name: assistant-quality
+  scale: {min: 1, max: 5}
+  criteria:
+    - name: correctness
+      description: The answer is technically correct.
+      weight: 2
+    - name: clarity
+      description: The answer is easy to follow.
+  instructions: Penalize invented functions.
+The suite names its rubric with "rubric: assistant-quality"; -rubric
+overrides it with a rubric name or file.`
+
+func promptJudgeCommand(a *app, fs *flag.FlagSet) runFunc {
+	rubricName := fs.String("rubric", "", "rubric name or file, overriding the suite's")
+	judgeModel := fs.String("judge-model", "models/gemini-2.5-pro", "model that judges the outputs")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) < 1 || len(args) > 3 {
+			return fmt.Errorf("expected a suite and up to two versions")
+		}
+		suite, err := eval.LoadSuite(args[0])
+		if err != nil {
+			return err
+		}
+		versions := args[1:]
+		if len(versions) == 0 {
+			versions = []string{""}
+		}
+
+		name := *rubricName
+		if name == "" {
+			name = suite.Rubric
+		}
+		if name == "" {
+			return fmt.Errorf("suite %s names no rubric; use -rubric", args[0])
+		}
+		dir, err := a.promptsDir()
+		if err != nil {
+			return err
+		}
+		rubric, err := eval.LoadRubric(eval.RubricFile(dir, name))
+		if err != nil {
+			return err
+		}
+
+		profiles, err := a.profiles()
+		if err != nil {
+			return err
+		}
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+		generator := &gemini.GenAIContentGenerator{Client: client}
+		runner := &eval.Runner{Settings: a.batchSettings(profiles), Generator: generator}
+
+		var reports []*eval.Report
+		for _, version := range versions {
+			a.logf("running %s (%d cases)", args[0], len(suite.Cases))
+			report, err := runner.Run(ctx, suite, version)
+			if err != nil {
+				return fmt.Errorf("suite %s: %w", args[0], err)
+			}
+			reports = append(reports, report)
+		}
+		if len(reports) == 1 {
+			reports = append(reports, nil)
+		}
+
+		a.logf("judging with %s against %s", *judgeModel, rubric.Name)
+		judge := &eval.Judge{Generator: generator, Model: *judgeModel, Rubric: rubric}
+		judgement, err := judge.Evaluate(ctx, suite, reports[0], reports[1])
+		if err != nil {
+			return err
+		}
+
+		if a.output == outputJSON {
+			return printJSON(judgement)
+		}
+		judgement.Print(os.Stdout)
+		return nil
+	}
+}
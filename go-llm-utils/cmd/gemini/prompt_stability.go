@@ -0,0 +1,112 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/batch"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/eval"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+)
+
+const promptStabilityHelp = `Sends every input with every prompt -runs times, with the seeds counting
+up from -seed, and measures how much the outputs differ: the share of
+identical pairs, the word-level edit distance and, unless -embeddings=false,
+the cosine similarity of their embeddings. -fixed-seed sends every run with
+the same seed, to see what a fixed seed leaves to chance; -temperatures
+repeats the runs at each temperature.
+
+Prompts and inputs are given as for compare. A prompt is unstable when one
+of its inputs exceeds -max-edit-distance or falls below -min-similarity,
+and the command fails if any prompt is unstable.`
+
+func promptStabilityCommand(a *app, fs *flag.FlagSet) runFunc {
+	var inputs inputFlags
+	inputs.register(fs)
+	runs := fs.Int("runs", eval.DefaultRuns, "runs per input and temperature")
+	seed := fs.Int("seed", 5, "seed of the first run")
+	fixedSeed := fs.Bool("fixed-seed", false, "use the same seed for every run")
+	temperatures := fs.String("temperatures", "", "comma-separated temperatures to sample at (default the profile's)")
+	embeddings := fs.Bool("embeddings", true, "measure the embedding similarity of the outputs")
+	maxEdit := fs.Float64("max-edit-distance", eval.DefaultMaxEditDistance, "edit distance above which a prompt is unstable")
+	minSimilarity := fs.Float64("min-similarity", eval.DefaultMinSimilarity, "similarity below which a prompt is unstable")
+	concurrency := fs.Int("concurrency", batch.DefaultConcurrency, "maximum number of requests in flight")
+
+	return func(ctx context.Context, args []string) error {
+		if *runs < 2 {
+			return fmt.Errorf("-runs must be at least 2")
+		}
+		variants, err := a.variants(args)
+		if err != nil {
+			return err
+		}
+		in, err := inputs.load()
+		if err != nil {
+			return err
+		}
+		var temps []float32
+		for _, s := range strings.Split(*temperatures, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			t, err := strconv.ParseFloat(s, 32)
+			if err != nil {
+				return fmt.Errorf("invalid temperature %q", s)
+			}
+			temps = append(temps, float32(t))
+		}
+
+		profiles, err := a.profiles()
+		if err != nil {
+			return err
+		}
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+		runner := &eval.StabilityRunner{
+			Settings:     a.batchSettings(profiles),
+			Generator:    &gemini.GenAIContentGenerator{Client: client},
+			Concurrency:  *concurrency,
+			Runs:         *runs,
+			Seed:         int32(*seed),
+			FixedSeed:    *fixedSeed,
+			Temperatures: temps,
+		}
+		if isSet(fs, "max-edit-distance") {
+			runner.MaxEditDistance = maxEdit
+		}
+		if isSet(fs, "min-similarity") {
+			runner.MinSimilarity = minSimilarity
+		}
+		if *embeddings {
+			runner.Embedder = &gemini.TextEmbedder{
+				Embedder: &gemini.GenAIContentEmbedder{Client: client},
+				Model:    embeddingModel,
+			}
+		}
+
+		a.logf("running %d prompts on %d inputs %d times", len(variants), len(in), *runs)
+		report, err := runner.Run(ctx, variants, in)
+		if err != nil {
+			return err
+		}
+
+		if a.output == outputJSON {
+			if err := printJSON(report); err != nil {
+				return err
+			}
+		} else {
+			report.Print(os.Stdout)
+		}
+		if unstable := report.Unstable(); len(unstable) > 0 {
+			return fmt.Errorf("unstable prompts: %s", strings.Join(unstable, ", "))
+		}
+		return nil
+	}
+}
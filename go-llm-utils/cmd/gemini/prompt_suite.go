@@ -0,0 +1,120 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/eval"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+)
+
+// evalsDir holds the test suites, relative to the project root.
+const evalsDir = "evals"
+
+const promptTestHelp = `A suite is a YAML file that tests one prompt, used as the system
+instruction, against a list of inputs:
+  prompt: task-specific/expert-MQL5-MetaTrader5-assistant
+  version: v6
+  cases:
+    - name: include-files
+      input: How should I share code between two indicators?
+      vars: {name: value}
+      assert:
+        - contains: "#include"
+        - not_contains: "prev_calculated"
+        - regex: '\.mqh\b'
+        - json_schema: schema.json
+        - min_length: 200
+        - max_length: 6000
+Without arguments every suite in evals/ is run. Outputs are diffed against
+the golden files in <suite>.golden/; -golden update stores the outputs of
+passing cases as the new golden files and -golden strict fails cases whose
+output changed.`
+
+func promptTestCommand(a *app, fs *flag.FlagSet) runFunc {
+	version := fs.String("version", "", "prompt version to test instead of the one the suite names")
+	golden := fs.String("golden", "report", "golden outputs: "+strings.Join(eval.GoldenModes(), ", "))
+
+	return func(ctx context.Context, args []string) error {
+		mode, err := eval.ParseGoldenMode(*golden)
+		if err != nil {
+			return err
+		}
+		files, err := a.suiteFiles(args)
+		if err != nil {
+			return err
+		}
+
+		profiles, err := a.profiles()
+		if err != nil {
+			return err
+		}
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+		runner := &eval.Runner{
+			Settings:  a.batchSettings(profiles),
+			Generator: &gemini.GenAIContentGenerator{Client: client},
+			Golden:    mode,
+		}
+
+		var reports []*eval.Report
+		failed := 0
+		for _, file := range files {
+			suite, err := eval.LoadSuite(file)
+			if err != nil {
+				return err
+			}
+			a.logf("running %s (%d cases)", file, len(suite.Cases))
+			report, err := runner.Run(ctx, suite, *version)
+			if err != nil {
+				return fmt.Errorf("suite %s: %w", file, err)
+			}
+			reports = append(reports, report)
+			failed += report.Failed()
+			if a.output != outputJSON {
+				report.Print(os.Stdout)
+			}
+		}
+
+		if a.output == outputJSON {
+			if err := printJSON(reports); err != nil {
+				return err
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d cases failed", failed)
+		}
+		return nil
+	}
+}
+
+// suiteFiles returns the suites named on the command line, or every suite in
+// the evals directory of the project.
+func (a *app) suiteFiles(args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	root, err := a.projectRoot()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(root, evalsDir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no suites in %s", filepath.Join(root, evalsDir))
+	}
+	return files, nil
+}
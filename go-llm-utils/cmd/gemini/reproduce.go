@@ -0,0 +1,122 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+)
+
+const reproduceHelp = `The request is read from the provenance sidecar that generate -out writes
+next to the response (<file>` + gemini.ProvenanceSuffix + `) and sent again with the
+same model, config and contents; the -model and -profile flags are ignored.
+The new response goes next to the original with a timestamp in its name
+unless -out is given, and the command reports whether it matches. Only
+single responses written with generate -out have a sidecar; -all output
+cannot be reproduced.`
+
+func reproduceCommand(a *app, fs *flag.FlagSet) runFunc {
+	out := fs.String("out", "", "write the new response here instead of next to the original")
+	ifExists := fs.String("if-exists", "", "when the output exists: "+strings.Join(gemini.ExistsModes(), ", ")+` (default "timestamp" next to the original, "fail" for -out)`)
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a response file or its provenance sidecar")
+		}
+		original, err := gemini.LoadProvenance(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to load provenance: %w", err)
+		}
+		mode := *ifExists
+		switch {
+		case mode != "":
+		case *out != "":
+			mode = "fail"
+		default:
+			mode = "timestamp"
+		}
+		existsMode, err := gemini.ParseExistsMode(mode)
+		if err != nil {
+			return err
+		}
+		for _, file := range original.ChangedPromptFiles() {
+			a.logf("warning: %s changed since the original run; reproducing the recorded prompt", file)
+		}
+
+		output := *out
+		if output == "" {
+			output = original.Output
+		}
+		format := original.Format
+		if format == "" {
+			format = gemini.FormatForFile(output)
+		}
+		writer, err := gemini.NewResponseWriter(format, gemini.SelectFirst)
+		if err != nil {
+			return err
+		}
+
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		prov := gemini.NewProvenance(original.Model, original.Contents, original.Config)
+		prov.PromptFiles = original.PromptFiles
+		prov.Format = format
+
+		a.debugf(1, "reproducing with %s", original.Model)
+		response, err := gemini.Reproduce(ctx, &gemini.GenAIContentGenerator{Client: client}, prov)
+		if err != nil {
+			return err
+		}
+		prov.RecordResponse(response)
+		a.logReport(response)
+
+		text, err := gemini.ResponseText(response)
+		if err != nil {
+			return err
+		}
+		if output != "" {
+			if err := a.saveWithProvenance(ctx, output, writer, response, prov, gemini.FileOptions{IfExists: existsMode}); err != nil {
+				return err
+			}
+			a.compareProvenance(original, prov)
+		}
+
+		switch a.output {
+		case outputJSON:
+			return printJSON(prov)
+		case outputRaw:
+			fmt.Print(text)
+		default:
+			fmt.Println(text)
+		}
+		return nil
+	}
+}
+
+// compareProvenance reports how the reproduction differs from the original run.
+func (a *app) compareProvenance(original, reproduced *gemini.Provenance) {
+	if original.ModelVersion != reproduced.ModelVersion {
+		a.logf("model version changed from %s to %s", original.ModelVersion, reproduced.ModelVersion)
+	}
+	if original.SDKVersion != reproduced.SDKVersion {
+		a.logf("SDK version changed from %s to %s", original.SDKVersion, reproduced.SDKVersion)
+	}
+	switch {
+	case original.OutputSHA256 == "":
+		a.logf("the original output was not hashed; compare %s and %s by hand", original.Output, reproduced.Output)
+	case original.Format != reproduced.Format:
+		a.logf("the outputs use different formats and cannot be compared")
+	case original.Format == gemini.FormatMarkdown && original.OutputSHA256 == reproduced.OutputSHA256:
+		a.logf("reproduced response is identical to the original")
+	case original.Format == gemini.FormatMarkdown:
+		a.logf("reproduced response differs from the original")
+	default:
+		a.logf("%s output embeds timestamps; compare %s and %s by hand", original.Format, original.Output, reproduced.Output)
+	}
+}
@@ -0,0 +1,59 @@
+//revive:disable:package-comments,exported
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"google.golang.org/genai"
+)
+
+func countTokensCommand(a *app, fs *flag.FlagSet) runFunc {
+	var in promptInput
+	in.register(fs)
+
+	return func(ctx context.Context, args []string) error {
+		profile, err := a.settings(gemini.DefaultProfile)
+		if err != nil {
+			return err
+		}
+		prompt, err := in.prompt(a, args)
+		if err != nil {
+			return err
+		}
+
+		// The Gemini API does not accept a system instruction when counting
+		// tokens, so it is counted as part of the contents instead.
+		system, err := in.systemInstruction(a)
+		if err != nil {
+			return err
+		}
+		if system == "" {
+			system = profile.SystemInstruction
+		}
+		var contents []*genai.Content
+		if system != "" {
+			contents = append(contents, genai.NewContentFromText(system, genai.RoleUser))
+		}
+		contents = append(contents, genai.NewContentFromText(prompt, genai.RoleUser))
+
+		client, err := a.genaiClient(ctx)
+		if err != nil {
+			return err
+		}
+
+		counter := &gemini.GenAITokenCounter{Client: client}
+		response, err := counter.CountTokens(ctx, profile.Model, contents, nil)
+		if err != nil {
+			return fmt.Errorf("failed to count tokens: %w", err)
+		}
+
+		if a.output == outputJSON {
+			return printJSON(response)
+		}
+		fmt.Println(response.TotalTokens)
+		return nil
+	}
+}
@@ -0,0 +1,223 @@
+//revive:disable:package-comments,exported
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+)
+
+// maxLineSize bounds a single JSONL record.
+const maxLineSize = 16 * 1024 * 1024
+
+// Request is one record of a batch input file. The prompt is given either
+// inline or as a file; files may use template variables and partials.
+type Request struct {
+	// ID identifies the request in the results. It defaults to the line number.
+	ID         string       `json:"id,omitempty"`
+	Prompt     string       `json:"prompt,omitempty"`
+	PromptFile string       `json:"prompt_file,omitempty"`
+	System     string       `json:"system,omitempty"`
+	SystemFile string       `json:"system_file,omitempty"`
+	Vars       prompts.Vars `json:"vars,omitempty"`
+	// Model overrides the model of the profile.
+	Model   string `json:"model,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	// Seed and Temperature override the sampling settings of the profile.
+	Seed        *int32   `json:"seed,omitempty"`
+	Temperature *float32 `json:"temperature,omitempty"`
+}
+
+// Usage is the token usage of a response.
+type Usage struct {
+	PromptTokens   int32 `json:"prompt_tokens"`
+	ResponseTokens int32 `json:"response_tokens"`
+	ThoughtsTokens int32 `json:"thoughts_tokens,omitempty"`
+	TotalTokens    int32 `json:"total_tokens"`
+}
+
+// Result is one record of a batch output file.
+type Result struct {
+	ID           string `json:"id"`
+	Model        string `json:"model,omitempty"`
+	Text         string `json:"text,omitempty"`
+	FinishReason string `json:"finish_reason,omitempty"`
+	Usage        *Usage `json:"usage,omitempty"`
+	LatencyMS    int64  `json:"latency_ms"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ReadRequests parses a JSONL stream of requests. Blank lines are skipped
+// and IDs must be unique.
+func ReadRequests(r io.Reader) ([]Request, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var requests []Request
+	seen := map[string]int{}
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, fmt.Errorf("line %d: failed to parse request: %w", line, err)
+		}
+		if req.ID == "" {
+			req.ID = strconv.Itoa(line)
+		}
+		if err := req.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if first, ok := seen[req.ID]; ok {
+			return nil, fmt.Errorf("line %d: id %q already used on line %d", line, req.ID, first)
+		}
+		seen[req.ID] = line
+		requests = append(requests, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ReadRequestsFile parses a JSONL file of requests.
+func ReadRequestsFile(filename string) ([]Request, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRequests(f)
+}
+
+func (r Request) validate() error {
+	if (r.Prompt == "") == (r.PromptFile == "") {
+		return fmt.Errorf("request %q needs exactly one of prompt and prompt_file", r.ID)
+	}
+	if r.System != "" && r.SystemFile != "" {
+		return fmt.Errorf("request %q has both system and system_file", r.ID)
+	}
+	return nil
+}
+
+// Completed returns the IDs that already have a successful result in the
+// output file. Failed results are not counted, so they are retried. A
+// missing file means nothing is done yet, and a truncated last line left by
+// a crash is ignored.
+func Completed(filename string) (map[string]bool, error) {
+	done := map[string]bool{}
+
+	f, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	var pending error
+	for line := 1; scanner.Scan(); line++ {
+		if pending != nil {
+			return nil, pending
+		}
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var res Result
+		if err := json.Unmarshal(data, &res); err != nil {
+			// Only the last line may be cut short.
+			pending = fmt.Errorf("line %d of %s: failed to parse result: %w", line, filename, err)
+			continue
+		}
+		if res.Error == "" {
+			done[res.ID] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+	return done, nil
+}
+
+// Pending returns the requests without a successful result.
+func Pending(requests []Request, done map[string]bool) []Request {
+	var pending []Request
+	for _, req := range requests {
+		if !done[req.ID] {
+			pending = append(pending, req)
+		}
+	}
+	return pending
+}
+
+// openResults opens the output file for appending, first dropping a
+// truncated last line left by a crash so the file stays valid JSONL.
+func openResults(filename string) (*os.File, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := dropPartialLine(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func dropPartialLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	end := info.Size()
+	buf := make([]byte, 4096)
+	for pos := end; pos > 0; {
+		n := min(int64(len(buf)), pos)
+		pos -= n
+		if _, err := f.ReadAt(buf[:n], pos); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			if pos+int64(i)+1 == end {
+				return nil
+			}
+			return f.Truncate(pos + int64(i) + 1)
+		}
+	}
+	return f.Truncate(0)
+}
+
+// AppendResults appends results to the output file in the format written by
+// Runner, so downloaded batch job results and local runs share a file and
+// failed requests can be retried with a local run.
+func AppendResults(filename string, results []Result) error {
+	f, err := openResults(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open results file: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, res := range results {
+		if err := enc.Encode(res); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write result %q: %w", res.ID, err)
+		}
+	}
+	return f.Close()
+}
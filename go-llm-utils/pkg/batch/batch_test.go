@@ -0,0 +1,124 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRequests(t *testing.T) {
+	t.Parallel()
+
+	input := `{"id": "greet", "prompt": "Hello {{ .name }}", "vars": {"name": "Ada"}, "model": "models/gemini-2.5-flash"}
+
+{"prompt_file": "user/hello.md", "profile": "prompt-engineering", "system": "Be brief."}
+`
+	requests, err := ReadRequests(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+
+	assert.Equal(t, Request{
+		ID:     "greet",
+		Prompt: "Hello {{ .name }}",
+		Vars:   prompts.Vars{"name": "Ada"},
+		Model:  "models/gemini-2.5-flash",
+	}, requests[0])
+	assert.Equal(t, "3", requests[1].ID, "id defaults to the line number")
+	assert.Equal(t, "user/hello.md", requests[1].PromptFile)
+}
+
+func TestReadRequestsErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"invalid json", `{"prompt": `, "line 1: failed to parse request"},
+		{"no prompt", `{"id": "a"}`, `line 1: request "a" needs exactly one of prompt and prompt_file`},
+		{"both prompts", `{"id": "a", "prompt": "x", "prompt_file": "y.md"}`, `line 1: request "a" needs exactly one of prompt and prompt_file`},
+		{"both systems", `{"id": "a", "prompt": "x", "system": "s", "system_file": "s.md"}`, `line 1: request "a" has both system and system_file`},
+		{"duplicate id", "{\"id\": \"a\", \"prompt\": \"x\"}\n{\"id\": \"a\", \"prompt\": \"y\"}", `line 2: id "a" already used on line 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ReadRequests(strings.NewReader(tt.input))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestCompleted(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	done, err := Completed(filepath.Join(dir, "missing.jsonl"))
+	require.NoError(t, err)
+	assert.Empty(t, done)
+
+	output := filepath.Join(dir, "results.jsonl")
+	results := `{"id": "a", "text": "ok", "latency_ms": 3}
+{"id": "b", "error": "failed to generate content: quota", "latency_ms": 1}
+{"id": "c", "te`
+	require.NoError(t, os.WriteFile(output, []byte(results), 0644))
+
+	done, err = Completed(output)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true}, done)
+
+	requests := []Request{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	assert.Equal(t, []Request{{ID: "b"}, {ID: "c"}}, Pending(requests, done))
+}
+
+func TestCompletedCorruptMiddleLine(t *testing.T) {
+	t.Parallel()
+
+	output := filepath.Join(t.TempDir(), "results.jsonl")
+	require.NoError(t, os.WriteFile(output, []byte("{\"id\": \nnot json\n{\"id\": \"a\"}\n"), 0644))
+
+	_, err := Completed(output)
+	assert.ErrorContains(t, err, "line 1 of")
+}
+
+func TestOpenResultsDropsTruncatedLine(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{"new file", "", `{"id": "b"}` + "\n"},
+		{"complete", `{"id": "a"}` + "\n", `{"id": "a"}` + "\n" + `{"id": "b"}` + "\n"},
+		{"truncated", `{"id": "a"}` + "\n" + `{"id": "b", "te`, `{"id": "a"}` + "\n" + `{"id": "b"}` + "\n"},
+		{"only truncated", `{"id": "b", "te`, `{"id": "b"}` + "\n"},
+		{"long truncated", `{"id": "a"}` + "\n" + strings.Repeat("x", 10000), `{"id": "a"}` + "\n" + `{"id": "b"}` + "\n"},
+	}
+
+	for i, tt := range tests {
+		output := filepath.Join(dir, fmt.Sprintf("results-%d.jsonl", i))
+		if tt.existing != "" {
+			require.NoError(t, os.WriteFile(output, []byte(tt.existing), 0644))
+		}
+
+		f, err := openResults(output)
+		require.NoError(t, err, tt.name)
+		_, err = f.WriteString(`{"id": "b"}` + "\n")
+		require.NoError(t, err, tt.name)
+		require.NoError(t, f.Close(), tt.name)
+
+		data, err := os.ReadFile(output)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, string(data), tt.name)
+	}
+}
@@ -0,0 +1,250 @@
+//revive:disable:package-comments,exported
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/fsutil"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"google.golang.org/genai"
+)
+
+// DefaultPollInterval is how often Wait checks on jobs when Submitter.PollInterval is not set.
+const DefaultPollInterval = 30 * time.Second
+
+// Job is a submitted batch job. A job runs a single model, so requests are
+// split into one job per model; IDs lists the requests of the job in the
+// order the API returns their responses.
+type Job struct {
+	Name  string         `json:"name"`
+	Model string         `json:"model"`
+	IDs   []string       `json:"ids"`
+	State genai.JobState `json:"state,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// Manifest records the jobs created for one requests file, so their status
+// and results can be fetched later from another process.
+type Manifest struct {
+	Input     string    `json:"input,omitempty"`
+	Submitted time.Time `json:"submitted"`
+	Jobs      []*Job    `json:"jobs"`
+}
+
+// LoadManifest reads a manifest written by Save.
+func LoadManifest(filename string) (*Manifest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %q: %w", filename, err)
+	}
+	return &m, nil
+}
+
+// Save writes the manifest as indented JSON.
+func (m *Manifest) Save(filename string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := fsutil.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest %q: %w", filename, err)
+	}
+	return nil
+}
+
+// Done reports whether every job has reached a terminal state.
+func (m *Manifest) Done() bool {
+	for _, job := range m.Jobs {
+		if !IsTerminal(job.State) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsTerminal reports whether a job in state will not change any more.
+func IsTerminal(state genai.JobState) bool {
+	switch state {
+	case genai.JobStateSucceeded, genai.JobStatePartiallySucceeded, genai.JobStateFailed,
+		genai.JobStateCancelled, genai.JobStateExpired:
+		return true
+	}
+	return false
+}
+
+// Submitter runs requests as asynchronous Gemini batch jobs, which are
+// cheaper than individual calls but may take hours to complete. Requests are
+// sent inline, which the API limits to about 20MB per job.
+type Submitter struct {
+	Settings
+	Jobs         gemini.BatchJobClient
+	PollInterval time.Duration
+}
+
+// Submit creates one job per model. If creating a job fails, the manifest of
+// the jobs created so far is returned along with the error, so they can be
+// tracked or canceled.
+func (s *Submitter) Submit(ctx context.Context, requests []Request, displayName string) (*Manifest, error) {
+	type group struct {
+		ids     []string
+		inlined []*genai.InlinedRequest
+	}
+	groups := map[string]*group{}
+	var models []string
+
+	for _, req := range requests {
+		model, contents, config, err := s.Prepare(req)
+		if err != nil {
+			return nil, fmt.Errorf("request %q: %w", req.ID, err)
+		}
+		g, ok := groups[model]
+		if !ok {
+			g = &group{}
+			groups[model] = g
+			models = append(models, model)
+		}
+		g.ids = append(g.ids, req.ID)
+		g.inlined = append(g.inlined, &genai.InlinedRequest{Contents: contents, Config: config})
+	}
+
+	m := &Manifest{Submitted: time.Now().UTC().Truncate(time.Second)}
+	for i, model := range models {
+		g := groups[model]
+		name := displayName
+		if len(models) > 1 {
+			name = fmt.Sprintf("%s-%d", displayName, i+1)
+		}
+
+		job, err := s.Jobs.Create(ctx, model,
+			&genai.BatchJobSource{InlinedRequests: g.inlined},
+			&genai.CreateBatchJobConfig{DisplayName: name},
+		)
+		if err != nil {
+			return m, fmt.Errorf("failed to create batch job for %s: %w", model, err)
+		}
+		m.Jobs = append(m.Jobs, &Job{Name: job.Name, Model: model, IDs: g.ids, State: job.State})
+	}
+	return m, nil
+}
+
+// Refresh updates the state of the jobs that have not finished yet.
+func (s *Submitter) Refresh(ctx context.Context, m *Manifest) error {
+	for _, job := range m.Jobs {
+		if IsTerminal(job.State) {
+			continue
+		}
+		remote, err := s.Jobs.Get(ctx, job.Name, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get batch job %s: %w", job.Name, err)
+		}
+		job.State = remote.State
+		if remote.Error != nil {
+			job.Error = remote.Error.Message
+		}
+	}
+	return nil
+}
+
+// Wait polls until every job has finished. progress, if not nil, is called
+// after each poll.
+func (s *Submitter) Wait(ctx context.Context, m *Manifest, progress func(*Manifest)) error {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	for {
+		if err := s.Refresh(ctx, m); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(m)
+		}
+		if m.Done() {
+			return nil
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Cancel cancels the jobs that have not finished yet.
+func (s *Submitter) Cancel(ctx context.Context, m *Manifest) error {
+	for _, job := range m.Jobs {
+		if IsTerminal(job.State) {
+			continue
+		}
+		if err := s.Jobs.Cancel(ctx, job.Name, nil); err != nil {
+			return fmt.Errorf("failed to cancel batch job %s: %w", job.Name, err)
+		}
+	}
+	return s.Refresh(ctx, m)
+}
+
+// Results downloads the responses of finished jobs and maps them back to
+// request IDs. Every request of a failed, canceled or expired job gets a
+// result with the job error. It fails if a job is still running.
+func (s *Submitter) Results(ctx context.Context, m *Manifest) ([]Result, error) {
+	var results []Result
+	for _, job := range m.Jobs {
+		remote, err := s.Jobs.Get(ctx, job.Name, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get batch job %s: %w", job.Name, err)
+		}
+		job.State = remote.State
+
+		switch remote.State {
+		case genai.JobStateSucceeded, genai.JobStatePartiallySucceeded:
+			jobResults, err := jobResults(job, remote)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, jobResults...)
+		case genai.JobStateFailed, genai.JobStateCancelled, genai.JobStateExpired:
+			message := fmt.Sprintf("batch job %s ended in %s", job.Name, remote.State)
+			if remote.Error != nil && remote.Error.Message != "" {
+				message += ": " + remote.Error.Message
+			}
+			for _, id := range job.IDs {
+				results = append(results, Result{ID: id, Model: job.Model, Error: message})
+			}
+		default:
+			return nil, fmt.Errorf("batch job %s is still %s", job.Name, remote.State)
+		}
+	}
+	return results, nil
+}
+
+func jobResults(job *Job, remote *genai.BatchJob) ([]Result, error) {
+	var responses []*genai.InlinedResponse
+	if remote.Dest != nil {
+		responses = remote.Dest.InlinedResponses
+	}
+	if len(responses) != len(job.IDs) {
+		return nil, fmt.Errorf("batch job %s returned %d responses for %d requests", job.Name, len(responses), len(job.IDs))
+	}
+
+	results := make([]Result, len(job.IDs))
+	for i, id := range job.IDs {
+		res := Result{ID: id, Model: job.Model}
+		if r := responses[i]; r.Error != nil {
+			res.Error = "batch request failed: " + r.Error.Message
+		} else {
+			resultFromResponse(&res, r.Response)
+		}
+		results[i] = res
+	}
+	return results, nil
+}
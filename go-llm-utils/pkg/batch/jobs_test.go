@@ -0,0 +1,153 @@
+package batch
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func TestSubmitterRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	gen := &echoGenerator{}
+	jobs := &LocalJobs{Generator: gen}
+	submitter := &Submitter{Jobs: jobs, PollInterval: time.Millisecond, Settings: Settings{Model: "models/default"}}
+
+	requests := []Request{
+		{ID: "a", Prompt: "one"},
+		{ID: "b", Prompt: "two", Model: "models/other"},
+		{ID: "c", Prompt: "fail three"},
+		{ID: "d", Prompt: "four"},
+	}
+
+	m, err := submitter.Submit(context.Background(), requests, "eval")
+	require.NoError(t, err)
+	require.Len(t, m.Jobs, 2, "one job per model")
+	assert.Equal(t, "models/default", m.Jobs[0].Model)
+	assert.Equal(t, []string{"a", "c", "d"}, m.Jobs[0].IDs)
+	assert.Equal(t, []string{"b"}, m.Jobs[1].IDs)
+	assert.Equal(t, genai.JobStatePending, m.Jobs[0].State)
+	assert.False(t, m.Done())
+
+	_, err = submitter.Results(context.Background(), m)
+	assert.ErrorContains(t, err, "is still JOB_STATE_RUNNING")
+
+	var polls int
+	require.NoError(t, submitter.Wait(context.Background(), m, func(*Manifest) { polls++ }))
+	assert.True(t, m.Done())
+	assert.Equal(t, 2, polls, "the first job was already running, the second needs another poll")
+
+	// The manifest survives a round trip through a file.
+	filename := filepath.Join(t.TempDir(), "requests.job.json")
+	require.NoError(t, m.Save(filename))
+	m, err = LoadManifest(filename)
+	require.NoError(t, err)
+
+	results, err := submitter.Results(context.Background(), m)
+	require.NoError(t, err)
+	byID := map[string]Result{}
+	for _, res := range results {
+		byID[res.ID] = res
+	}
+	require.Len(t, byID, 4)
+	assert.Equal(t, "echo: one", byID["a"].Text)
+	assert.Equal(t, "echo: two", byID["b"].Text)
+	assert.Equal(t, "models/other", byID["b"].Model)
+	assert.Equal(t, "echo: four", byID["d"].Text)
+	assert.Equal(t, &Usage{PromptTokens: 3, ResponseTokens: 5, TotalTokens: 8}, byID["d"].Usage)
+	assert.Equal(t, "batch request failed: quota exceeded", byID["c"].Error)
+}
+
+func TestSubmitterCancel(t *testing.T) {
+	t.Parallel()
+
+	submitter := &Submitter{Jobs: &LocalJobs{Generator: &echoGenerator{}}}
+	m, err := submitter.Submit(context.Background(), []Request{{ID: "a", Prompt: "one"}}, "eval")
+	require.NoError(t, err)
+
+	require.NoError(t, submitter.Cancel(context.Background(), m))
+	assert.Equal(t, genai.JobStateCancelled, m.Jobs[0].State)
+
+	results, err := submitter.Results(context.Background(), m)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a", results[0].ID)
+	assert.Contains(t, results[0].Error, "ended in JOB_STATE_CANCELLED")
+}
+
+func TestSubmitterRejectsInvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	jobs := &LocalJobs{Generator: &echoGenerator{}}
+	submitter := &Submitter{Jobs: jobs}
+	_, err := submitter.Submit(context.Background(), []Request{
+		{ID: "a", Prompt: "one"},
+		{ID: "b", Prompt: "two", Profile: "nope"},
+	}, "eval")
+	assert.ErrorContains(t, err, `request "b": unknown profile "nope"`)
+	assert.Empty(t, jobs.jobs, "no job is created when a request is invalid")
+}
+
+func TestAppendResults(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "results.jsonl")
+	require.NoError(t, AppendResults(filename, []Result{{ID: "a", Text: "x"}, {ID: "b", Error: "boom"}}))
+	require.NoError(t, AppendResults(filename, []Result{{ID: "b", Text: "y"}}))
+
+	done, err := Completed(filename)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true, "b": true}, done)
+}
+
+// blockingGenerator answers once release is closed and reports each call on started.
+type blockingGenerator struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (g *blockingGenerator) GenerateContent(ctx context.Context, _ string, _ []*genai.Content, _ *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	g.started <- struct{}{}
+	<-g.release
+	return &genai.GenerateContentResponse{}, ctx.Err()
+}
+
+func TestLocalJobsCancelWhileRunning(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	gen := &blockingGenerator{started: make(chan struct{}, 2), release: make(chan struct{})}
+	jobs := &LocalJobs{Generator: gen}
+	requests := []*genai.InlinedRequest{
+		{Contents: []*genai.Content{genai.NewContentFromText("one", genai.RoleUser)}},
+		{Contents: []*genai.Content{genai.NewContentFromText("two", genai.RoleUser)}},
+	}
+	job, err := jobs.Create(ctx, "models/m", &genai.BatchJobSource{InlinedRequests: requests}, nil)
+	require.NoError(t, err)
+	_, err = jobs.Get(ctx, job.Name, nil)
+	require.NoError(t, err)
+
+	done := make(chan *genai.BatchJob)
+	go func() {
+		finished, _ := jobs.Get(ctx, job.Name, nil)
+		done <- finished
+	}()
+	<-gen.started
+
+	// The job is running its first request; Get and Cancel do not wait for it.
+	running, err := jobs.Get(ctx, job.Name, nil)
+	require.NoError(t, err)
+	assert.Equal(t, genai.JobStateRunning, running.State)
+	require.NoError(t, jobs.Cancel(ctx, job.Name, nil))
+
+	close(gen.release)
+	finished := <-done
+	assert.Equal(t, genai.JobStateCancelled, finished.State)
+	assert.Nil(t, finished.Dest, "a cancelled job publishes no results")
+	assert.Empty(t, gen.started, "no request runs after the cancel")
+}
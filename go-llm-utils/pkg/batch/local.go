@@ -0,0 +1,137 @@
+//revive:disable:package-comments,exported
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"google.golang.org/genai"
+)
+
+// LocalJobs implements gemini.BatchJobClient in process, running the inlined
+// requests of a job through Generator. It stands in for the Batch API in
+// tests and dry runs. A job is pending after Create, running after the first
+// Get and finished after the second, so polling code sees every state.
+type LocalJobs struct {
+	Generator gemini.ContentGenerator
+
+	mu   sync.Mutex
+	jobs map[string]*localJob
+	next int
+}
+
+type localJob struct {
+	job      genai.BatchJob
+	requests []*genai.InlinedRequest
+	// started is set once a Get has begun running the requests.
+	started bool
+}
+
+func (l *LocalJobs) Create(_ context.Context, model string, src *genai.BatchJobSource, config *genai.CreateBatchJobConfig) (*genai.BatchJob, error) {
+	if src == nil || len(src.InlinedRequests) == 0 {
+		return nil, fmt.Errorf("batch job needs inlined requests")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.jobs == nil {
+		l.jobs = map[string]*localJob{}
+	}
+	l.next++
+
+	job := &localJob{
+		job: genai.BatchJob{
+			Name:       fmt.Sprintf("batches/local-%d", l.next),
+			Model:      model,
+			State:      genai.JobStatePending,
+			CreateTime: time.Now(),
+		},
+		requests: src.InlinedRequests,
+	}
+	if config != nil {
+		job.job.DisplayName = config.DisplayName
+	}
+	l.jobs[job.job.Name] = job
+
+	copied := job.job
+	return &copied, nil
+}
+
+func (l *LocalJobs) Get(ctx context.Context, name string, _ *genai.GetBatchJobConfig) (*genai.BatchJob, error) {
+	l.mu.Lock()
+	job, ok := l.jobs[name]
+	if !ok {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("batch job %q not found", name)
+	}
+
+	start := false
+	switch job.job.State {
+	case genai.JobStatePending:
+		job.job.State = genai.JobStateRunning
+		job.job.StartTime = time.Now()
+	case genai.JobStateRunning:
+		start = !job.started
+		job.started = true
+	}
+	copied := job.job
+	l.mu.Unlock()
+
+	if !start {
+		return &copied, nil
+	}
+	return l.run(ctx, job), nil
+}
+
+func (l *LocalJobs) Cancel(_ context.Context, name string, _ *genai.CancelBatchJobConfig) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	job, ok := l.jobs[name]
+	if !ok {
+		return fmt.Errorf("batch job %q not found", name)
+	}
+	if !IsTerminal(job.job.State) {
+		job.job.State = genai.JobStateCancelled
+		job.job.EndTime = time.Now()
+	}
+	return nil
+}
+
+// run executes the requests of a job without holding the lock, so Get and
+// Cancel stay responsive, and publishes the results unless the job was
+// cancelled meanwhile. Request failures become per-request errors like in
+// the Batch API.
+func (l *LocalJobs) run(ctx context.Context, job *localJob) *genai.BatchJob {
+	dest := &genai.BatchJobDestination{}
+	for _, req := range job.requests {
+		if l.cancelled(job) {
+			break
+		}
+		resp, err := l.Generator.GenerateContent(ctx, job.job.Model, req.Contents, req.Config)
+		if err != nil {
+			dest.InlinedResponses = append(dest.InlinedResponses, &genai.InlinedResponse{Error: &genai.JobError{Message: err.Error()}})
+			continue
+		}
+		dest.InlinedResponses = append(dest.InlinedResponses, &genai.InlinedResponse{Response: resp})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if job.job.State == genai.JobStateRunning {
+		job.job.Dest = dest
+		job.job.State = genai.JobStateSucceeded
+		job.job.EndTime = time.Now()
+	}
+	copied := job.job
+	return &copied
+}
+
+func (l *LocalJobs) cancelled(job *localJob) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return job.job.State == genai.JobStateCancelled
+}
@@ -0,0 +1,252 @@
+//revive:disable:package-comments,exported
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"google.golang.org/genai"
+)
+
+// DefaultConcurrency is the number of requests in flight when Runner.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// Settings turn requests into model calls. They are shared by the local
+// Runner and the batch job Submitter.
+type Settings struct {
+	// Profiles defaults to gemini.DefaultProfiles.
+	Profiles gemini.Profiles
+	// Profile is used for requests that do not name one; it defaults to gemini.DefaultProfile.
+	Profile string
+	// Model is used for requests that do not name one, overriding the profile.
+	Model string
+	// Renderer renders prompts and system instructions; a zero Renderer is used if nil.
+	Renderer *prompts.Renderer
+	// PromptsDir resolves relative prompt_file and system_file paths.
+	PromptsDir string
+}
+
+// Runner executes batch requests against a content generator.
+type Runner struct {
+	Settings
+	Generator   gemini.ContentGenerator
+	Concurrency int
+}
+
+// Stats summarizes a run.
+type Stats struct {
+	Total     int
+	Skipped   int
+	Succeeded int
+	Failed    int
+}
+
+// RunFile runs the requests of input that have no successful result in
+// output yet and appends their results to output, so an interrupted run can
+// be resumed by running it again.
+func (r *Runner) RunFile(ctx context.Context, input, output string) (Stats, error) {
+	requests, err := ReadRequestsFile(input)
+	if err != nil {
+		return Stats{}, err
+	}
+	done, err := Completed(output)
+	if err != nil {
+		return Stats{}, err
+	}
+	pending := Pending(requests, done)
+
+	f, err := openResults(output)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer f.Close()
+
+	stats, err := r.Run(ctx, pending, f)
+	stats.Total = len(requests)
+	stats.Skipped = len(requests) - len(pending)
+	return stats, err
+}
+
+// Run executes requests with bounded concurrency and writes one JSON line
+// per request to w as soon as it completes. Failed requests are recorded in
+// the results rather than stopping the run. When ctx is canceled no new
+// requests are started and Run returns the context error.
+func (r *Runner) Run(ctx context.Context, requests []Request, w io.Writer) (Stats, error) {
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	stats := Stats{Total: len(requests)}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		writeErr error
+	)
+	enc := json.NewEncoder(w)
+	sem := make(chan struct{}, concurrency)
+
+loop:
+	for _, req := range requests {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func(req Request) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res := r.execute(ctx, req)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if res.Error == "" {
+				stats.Succeeded++
+			} else {
+				stats.Failed++
+			}
+			if writeErr == nil {
+				if err := enc.Encode(res); err != nil {
+					writeErr = fmt.Errorf("failed to write result %q: %w", res.ID, err)
+				}
+			}
+		}(req)
+	}
+	wg.Wait()
+
+	if writeErr != nil {
+		return stats, writeErr
+	}
+	return stats, ctx.Err()
+}
+
+// execute runs a single request; every failure ends up in Result.Error.
+func (r *Runner) execute(ctx context.Context, req Request) Result {
+	res := Result{ID: req.ID}
+
+	model, contents, config, err := r.Prepare(req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Model = model
+
+	start := time.Now()
+	resp, err := r.Generator.GenerateContent(ctx, model, contents, config)
+	res.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = fmt.Sprintf("failed to generate content: %v", err)
+		return res
+	}
+
+	resultFromResponse(&res, resp)
+	return res
+}
+
+// resultFromResponse fills in the text, finish reason and usage of resp, or
+// the reason it carries no text.
+func resultFromResponse(res *Result, resp *genai.GenerateContentResponse) {
+	if resp != nil && resp.UsageMetadata != nil {
+		res.Usage = &Usage{
+			PromptTokens:   resp.UsageMetadata.PromptTokenCount,
+			ResponseTokens: resp.UsageMetadata.CandidatesTokenCount,
+			ThoughtsTokens: resp.UsageMetadata.ThoughtsTokenCount,
+			TotalTokens:    resp.UsageMetadata.TotalTokenCount,
+		}
+	}
+	if resp != nil && len(resp.Candidates) > 0 {
+		res.FinishReason = string(resp.Candidates[0].FinishReason)
+	}
+
+	text, err := gemini.ResponseText(resp)
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	res.Text = text
+}
+
+// Prepare resolves the profile, model and prompts of a request into the
+// arguments of a GenerateContent call.
+func (s *Settings) Prepare(req Request) (string, []*genai.Content, *genai.GenerateContentConfig, error) {
+	profiles := s.Profiles
+	if profiles == nil {
+		profiles = gemini.DefaultProfiles()
+	}
+	name := req.Profile
+	if name == "" {
+		name = s.Profile
+	}
+	if name == "" {
+		name = gemini.DefaultProfile
+	}
+	profile, err := profiles.Get(name)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	model := profile.Model
+	if s.Model != "" {
+		model = s.Model
+	}
+	if req.Model != "" {
+		model = req.Model
+	}
+	if model == "" {
+		model = gemini.DefaultModel
+	}
+
+	if req.Seed != nil {
+		profile.Seed = req.Seed
+	}
+	if req.Temperature != nil {
+		profile.Temperature = req.Temperature
+	}
+
+	renderer := s.Renderer
+	if renderer == nil {
+		renderer = &prompts.Renderer{}
+	}
+
+	var prompt string
+	if req.PromptFile != "" {
+		prompt, err = renderer.RenderFile(s.path(req.PromptFile), req.Vars)
+	} else {
+		prompt, err = renderer.RenderText("prompt", []byte(req.Prompt), req.Vars)
+	}
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	switch {
+	case req.SystemFile != "":
+		profile.SystemInstruction, err = renderer.RenderFile(s.path(req.SystemFile), req.Vars)
+	case req.System != "":
+		profile.SystemInstruction, err = renderer.RenderText("system", []byte(req.System), req.Vars)
+	}
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to render system instruction: %w", err)
+	}
+
+	contents := []*genai.Content{
+		genai.NewContentFromText(prompt, genai.RoleUser),
+	}
+	return model, contents, profile.GenerateContentConfig(), nil
+}
+
+func (s *Settings) path(file string) string {
+	if filepath.IsAbs(file) || s.PromptsDir == "" {
+		return file
+	}
+	return filepath.Join(s.PromptsDir, file)
+}
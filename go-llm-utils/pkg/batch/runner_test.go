@@ -0,0 +1,203 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+// echoGenerator answers every prompt with "echo: <prompt>", fails prompts
+// starting with "fail" and tracks how many calls run at once.
+type echoGenerator struct {
+	mu       sync.Mutex
+	calls    []generateCall
+	inFlight int
+	peak     int
+	delay    time.Duration
+}
+
+type generateCall struct {
+	model  string
+	prompt string
+	config *genai.GenerateContentConfig
+}
+
+func (g *echoGenerator) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	prompt := contents[0].Parts[0].Text
+
+	g.mu.Lock()
+	g.calls = append(g.calls, generateCall{model, prompt, config})
+	g.inFlight++
+	g.peak = max(g.peak, g.inFlight)
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.inFlight--
+		g.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(g.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if strings.HasPrefix(prompt, "fail") {
+		return nil, errors.New("quota exceeded")
+	}
+	return &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content:      genai.NewContentFromText("echo: "+prompt, genai.RoleModel),
+			FinishReason: genai.FinishReasonStop,
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     3,
+			CandidatesTokenCount: 5,
+			TotalTokenCount:      8,
+		},
+	}, nil
+}
+
+func decodeResults(t *testing.T, data []byte) map[string]Result {
+	t.Helper()
+	results := map[string]Result{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var res Result
+		require.NoError(t, json.Unmarshal([]byte(line), &res))
+		results[res.ID] = res
+	}
+	return results
+}
+
+func TestRunnerRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "user"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user", "hello.md"), []byte("Hello {{ .name }}\n"), 0644))
+
+	gen := &echoGenerator{}
+	runner := &Runner{Generator: gen, Settings: Settings{PromptsDir: dir, Model: "models/flag-model"}}
+	requests := []Request{
+		{ID: "file", PromptFile: "user/hello.md", Vars: prompts.Vars{"name": "Ada"}},
+		{ID: "inline", Prompt: "ping", Model: "models/request-model", System: "Be brief."},
+		{ID: "profile", Prompt: "pong", Profile: gemini.PromptEngineeringProfile},
+		{ID: "sampling", Prompt: "sample", Seed: gemini.I32(5), Temperature: gemini.F32(0.9)},
+		{ID: "fails", Prompt: "fail please"},
+		{ID: "bad-profile", Prompt: "x", Profile: "nope"},
+	}
+
+	var out bytes.Buffer
+	stats, err := runner.Run(context.Background(), requests, &out)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Total: 6, Succeeded: 4, Failed: 2}, stats)
+
+	results := decodeResults(t, out.Bytes())
+	require.Len(t, results, 6)
+
+	assert.Equal(t, "echo: Hello Ada", results["file"].Text)
+	assert.Equal(t, "models/flag-model", results["file"].Model)
+	assert.Equal(t, "STOP", results["file"].FinishReason)
+	assert.Equal(t, &Usage{PromptTokens: 3, ResponseTokens: 5, TotalTokens: 8}, results["file"].Usage)
+	assert.Equal(t, "models/request-model", results["inline"].Model, "request model wins over the runner model")
+	assert.Equal(t, "failed to generate content: quota exceeded", results["fails"].Error)
+	assert.Contains(t, results["bad-profile"].Error, `unknown profile "nope"`)
+
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	for _, call := range gen.calls {
+		switch call.prompt {
+		case "ping":
+			require.NotNil(t, call.config.SystemInstruction)
+			assert.Equal(t, "Be brief.", call.config.SystemInstruction.Parts[0].Text)
+		case "pong":
+			assert.Equal(t, gemini.I32(12345), call.config.Seed, "prompt-engineering profile applied")
+		case "sample":
+			assert.Equal(t, gemini.I32(5), call.config.Seed)
+			assert.Equal(t, gemini.F32(0.9), call.config.Temperature, "request sampling wins over the profile")
+		}
+	}
+}
+
+func TestRunnerConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	gen := &echoGenerator{delay: 10 * time.Millisecond}
+	runner := &Runner{Generator: gen, Concurrency: 3}
+
+	var requests []Request
+	for _, id := range strings.Split("a b c d e f g h i j", " ") {
+		requests = append(requests, Request{ID: id, Prompt: id})
+	}
+
+	var out bytes.Buffer
+	stats, err := runner.Run(context.Background(), requests, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 10, stats.Succeeded)
+	assert.LessOrEqual(t, gen.peak, 3)
+	assert.Greater(t, gen.peak, 1)
+}
+
+func TestRunnerRunFileResumes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "requests.jsonl")
+	output := filepath.Join(dir, "results.jsonl")
+	require.NoError(t, os.WriteFile(input, []byte(`{"id": "a", "prompt": "one"}
+{"id": "b", "prompt": "two"}
+{"id": "c", "prompt": "three"}
+`), 0644))
+	// A previous run finished a, failed b and crashed while writing c.
+	require.NoError(t, os.WriteFile(output, []byte(`{"id": "a", "text": "echo: one", "latency_ms": 1}
+{"id": "b", "error": "failed to generate content: quota exceeded", "latency_ms": 1}
+{"id": "c", "te`), 0644))
+
+	gen := &echoGenerator{}
+	runner := &Runner{Generator: gen}
+	stats, err := runner.RunFile(context.Background(), input, output)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Total: 3, Skipped: 1, Succeeded: 2}, stats)
+
+	var prompts []string
+	for _, call := range gen.calls {
+		prompts = append(prompts, call.prompt)
+	}
+	sort.Strings(prompts)
+	assert.Equal(t, []string{"three", "two"}, prompts)
+
+	done, err := Completed(output)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true, "b": true, "c": true}, done)
+
+	// Nothing is left to do on a second run.
+	stats, err = runner.RunFile(context.Background(), input, output)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Total: 3, Skipped: 3}, stats)
+}
+
+func TestRunnerCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runner := &Runner{Generator: &echoGenerator{}, Concurrency: 1}
+	var out bytes.Buffer
+	_, err := runner.Run(ctx, []Request{{ID: "a", Prompt: "x"}, {ID: "b", Prompt: "y"}}, &out)
+	assert.ErrorIs(t, err, context.Canceled)
+}
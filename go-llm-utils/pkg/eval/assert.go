@@ -0,0 +1,153 @@
+//revive:disable:package-comments,exported
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Assertion checks one property of an output. Exactly one check is set;
+// lengths count characters.
+type Assertion struct {
+	Contains    string `yaml:"contains,omitempty" json:"contains,omitempty"`
+	NotContains string `yaml:"not_contains,omitempty" json:"not_contains,omitempty"`
+	Regex       string `yaml:"regex,omitempty" json:"regex,omitempty"`
+	NotRegex    string `yaml:"not_regex,omitempty" json:"not_regex,omitempty"`
+	// JSONSchema is an inline schema, or the name of a schema file relative
+	// to the suite. The output must be JSON, optionally in a code fence.
+	JSONSchema any  `yaml:"json_schema,omitempty" json:"json_schema,omitempty"`
+	MinLength  *int `yaml:"min_length,omitempty" json:"min_length,omitempty"`
+	MaxLength  *int `yaml:"max_length,omitempty" json:"max_length,omitempty"`
+	// IgnoreCase makes contains and not_contains case-insensitive.
+	IgnoreCase bool `yaml:"ignore_case,omitempty" json:"ignore_case,omitempty"`
+}
+
+func (a Assertion) validate() error {
+	set := 0
+	for _, ok := range []bool{
+		a.Contains != "", a.NotContains != "", a.Regex != "", a.NotRegex != "",
+		a.JSONSchema != nil, a.MinLength != nil, a.MaxLength != nil,
+	} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("needs exactly one of contains, not_contains, regex, not_regex, json_schema, min_length and max_length")
+	}
+	for _, expr := range []string{a.Regex, a.NotRegex} {
+		if expr == "" {
+			continue
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	return nil
+}
+
+// Check returns why output fails the assertion, or the empty string if it
+// passes. Schema files are resolved against dir.
+func (a Assertion) Check(output, dir string) string {
+	switch {
+	case a.Contains != "":
+		if !contains(output, a.Contains, a.IgnoreCase) {
+			return fmt.Sprintf("expected output to contain %q", a.Contains)
+		}
+	case a.NotContains != "":
+		if contains(output, a.NotContains, a.IgnoreCase) {
+			return fmt.Sprintf("expected output not to contain %q", a.NotContains)
+		}
+	case a.Regex != "":
+		if !regexp.MustCompile(a.Regex).MatchString(output) {
+			return fmt.Sprintf("expected output to match /%s/", a.Regex)
+		}
+	case a.NotRegex != "":
+		if m := regexp.MustCompile(a.NotRegex).FindString(output); m != "" {
+			return fmt.Sprintf("expected output not to match /%s/, found %q", a.NotRegex, m)
+		}
+	case a.MinLength != nil:
+		if n := utf8.RuneCountInString(output); n < *a.MinLength {
+			return fmt.Sprintf("expected at least %d characters, got %d", *a.MinLength, n)
+		}
+	case a.MaxLength != nil:
+		if n := utf8.RuneCountInString(output); n > *a.MaxLength {
+			return fmt.Sprintf("expected at most %d characters, got %d", *a.MaxLength, n)
+		}
+	case a.JSONSchema != nil:
+		return a.checkSchema(output, dir)
+	}
+	return ""
+}
+
+func contains(s, substr string, ignoreCase bool) bool {
+	if ignoreCase {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+	return strings.Contains(s, substr)
+}
+
+func (a Assertion) checkSchema(output, dir string) string {
+	schema, err := a.schema(dir)
+	if err != nil {
+		return err.Error()
+	}
+	var value any
+	if err := json.Unmarshal([]byte(ExtractJSON(output)), &value); err != nil {
+		return fmt.Sprintf("expected JSON output: %v", err)
+	}
+	if problems := validateSchema(schema, value, "$"); len(problems) > 0 {
+		return "output does not match the JSON schema: " + strings.Join(problems, "; ")
+	}
+	return ""
+}
+
+// schema returns the inline schema or loads the schema file, which may be
+// JSON or YAML.
+func (a Assertion) schema(dir string) (map[string]any, error) {
+	switch s := a.JSONSchema.(type) {
+	case map[string]any:
+		return s, nil
+	case string:
+		path := s
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JSON schema: %w", err)
+		}
+		var schema map[string]any
+		if err := yaml.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON schema %q: %w", s, err)
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("json_schema must be a schema or a file name, got %T", a.JSONSchema)
+}
+
+// ExtractJSON returns the contents of the first fenced code block of text,
+// or the trimmed text if it has none, since models like to wrap JSON in
+// ```json fences.
+func ExtractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return text
+	}
+	body := text[start+3:]
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+		body = body[nl+1:]
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimSpace(body)
+}
@@ -0,0 +1,394 @@
+//revive:disable:package-comments,exported
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/batch"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+)
+
+// Variant is one prompt of a comparison, used as the system instruction.
+type Variant struct {
+	Label string `json:"label"`
+	// File is the prompt file, relative to the prompts directory unless absolute.
+	File string `json:"file"`
+}
+
+// ResolveVariants turns prompt specs into variants. A spec is a library ID
+// with an optional @version, an ID pattern such as
+// "task-specific/trading-api-assistant/*" that selects every matching
+// prompt, or the name of a markdown file relative to the working directory.
+func ResolveVariants(lib *prompts.Library, specs []string) ([]Variant, error) {
+	var variants []Variant
+	for _, spec := range specs {
+		if strings.EqualFold(filepath.Ext(spec), ".md") {
+			file, err := filepath.Abs(spec)
+			if err != nil {
+				return nil, err
+			}
+			variants = append(variants, Variant{Label: strings.TrimSuffix(filepath.ToSlash(spec), filepath.Ext(spec)), File: file})
+			continue
+		}
+
+		id, version, _ := strings.Cut(spec, "@")
+		ids := []string{id}
+		if strings.ContainsAny(id, "*?[") {
+			ids = nil
+			for _, candidate := range lib.IDs() {
+				if ok, err := path.Match(id, candidate); err != nil {
+					return nil, fmt.Errorf("invalid prompt pattern %q: %w", id, err)
+				} else if ok {
+					ids = append(ids, candidate)
+				}
+			}
+			if len(ids) == 0 {
+				return nil, fmt.Errorf("no prompt matches %q", id)
+			}
+		}
+		for _, id := range ids {
+			p, err := lib.Get(id, version)
+			if err != nil {
+				return nil, err
+			}
+			label := p.ID
+			if len(lib.Versions(p.ID)) > 1 {
+				label += "@" + p.Version
+			}
+			variants = append(variants, Variant{Label: label, File: filepath.FromSlash(p.Path)})
+		}
+	}
+	shortenLabels(variants)
+	return variants, nil
+}
+
+// shortenLabels drops the directories that all labels share.
+func shortenLabels(variants []Variant) {
+	if len(variants) < 2 {
+		return
+	}
+	prefix := variants[0].Label
+	for _, v := range variants[1:] {
+		for !strings.HasPrefix(v.Label, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	prefix = prefix[:strings.LastIndex(prefix, "/")+1]
+	for i := range variants {
+		variants[i].Label = strings.TrimPrefix(variants[i].Label, prefix)
+	}
+}
+
+// Input is one user prompt of a comparison.
+type Input struct {
+	Name string       `json:"name"`
+	Text string       `json:"text"`
+	Vars prompts.Vars `json:"vars,omitempty"`
+}
+
+// Inputs returns the cases of the suite as comparison inputs.
+func (s *Suite) Inputs() []Input {
+	inputs := make([]Input, 0, len(s.Cases))
+	for _, c := range s.Cases {
+		inputs = append(inputs, Input{Name: c.Name, Text: c.Input, Vars: s.vars(c)})
+	}
+	return inputs
+}
+
+// Cell is the outcome of one input sent with one variant to one model.
+type Cell struct {
+	Input        string       `json:"input"`
+	Variant      string       `json:"variant"`
+	Model        string       `json:"model"`
+	Output       string       `json:"output,omitempty"`
+	FinishReason string       `json:"finish_reason,omitempty"`
+	Error        string       `json:"error,omitempty"`
+	Usage        *batch.Usage `json:"usage,omitempty"`
+	LatencyMS    int64        `json:"latency_ms"`
+}
+
+// Column summarizes the cells of one variant and model over every input.
+// The means cover the cells without errors; the total covers every call.
+type Column struct {
+	Variant            string  `json:"variant"`
+	Model              string  `json:"model"`
+	Errors             int     `json:"errors"`
+	MeanLatencyMS      float64 `json:"mean_latency_ms"`
+	MeanPromptTokens   float64 `json:"mean_prompt_tokens"`
+	MeanResponseTokens float64 `json:"mean_response_tokens"`
+	TotalTokens        int64   `json:"total_tokens"`
+}
+
+// Matrix is the outcome of a comparison. Cells are ordered by input, then
+// variant, then model.
+type Matrix struct {
+	Variants []Variant `json:"variants"`
+	Models   []string  `json:"models"`
+	Inputs   []Input   `json:"inputs"`
+	Cells    []Cell    `json:"cells"`
+	Columns  []Column  `json:"columns"`
+}
+
+// Cell returns the cell of input i, variant v and model m.
+func (mx *Matrix) Cell(i, v, m int) *Cell {
+	return &mx.Cells[(i*len(mx.Variants)+v)*len(mx.Models)+m]
+}
+
+// Comparer runs every input with every variant on every model.
+type Comparer struct {
+	// Settings resolve the profile and prompt files; the models replace
+	// Settings.Model.
+	batch.Settings
+	Generator   gemini.ContentGenerator
+	Concurrency int
+}
+
+// Run executes the matrix of variants × models × inputs with the bounded
+// concurrency of a batch run. Models default to the one the settings
+// resolve. Failed calls are recorded in their cells.
+func (c *Comparer) Run(ctx context.Context, variants []Variant, models []string, inputs []Input) (*Matrix, error) {
+	if len(models) == 0 {
+		model, _, _, err := c.Prepare(batch.Request{})
+		if err != nil {
+			return nil, err
+		}
+		models = []string{model}
+	}
+	mx := &Matrix{Variants: variants, Models: models, Inputs: inputs}
+
+	var requests []batch.Request
+	for i, in := range inputs {
+		for v, variant := range variants {
+			for m, model := range models {
+				mx.Cells = append(mx.Cells, Cell{Input: in.Name, Variant: variant.Label, Model: model})
+				requests = append(requests, batch.Request{
+					ID: cellID(i, v, m), Prompt: in.Text, SystemFile: variant.File, Model: model, Vars: in.Vars,
+				})
+			}
+		}
+	}
+
+	runner := &batch.Runner{Settings: c.Settings, Generator: c.Generator, Concurrency: c.Concurrency}
+	var buf bytes.Buffer
+	if _, err := runner.Run(ctx, requests, &buf); err != nil {
+		return nil, err
+	}
+
+	results := map[string]batch.Result{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var res batch.Result
+		if err := dec.Decode(&res); err != nil {
+			return nil, fmt.Errorf("failed to read comparison results: %w", err)
+		}
+		results[res.ID] = res
+	}
+	for i := range inputs {
+		for v := range variants {
+			for m := range models {
+				cell := mx.Cell(i, v, m)
+				res, ok := results[cellID(i, v, m)]
+				if !ok {
+					cell.Error = "not run"
+					continue
+				}
+				cell.Output, cell.FinishReason, cell.Error = res.Text, res.FinishReason, res.Error
+				cell.Usage, cell.LatencyMS = res.Usage, res.LatencyMS
+			}
+		}
+	}
+	mx.summarize()
+	return mx, nil
+}
+
+func cellID(i, v, m int) string {
+	return fmt.Sprintf("%d.%d.%d", i, v, m)
+}
+
+func (mx *Matrix) summarize() {
+	mx.Columns = nil
+	for v, variant := range mx.Variants {
+		for m, model := range mx.Models {
+			col := Column{Variant: variant.Label, Model: model}
+			var latency, promptTokens, responseTokens float64
+			ok := 0
+			for i := range mx.Inputs {
+				cell := mx.Cell(i, v, m)
+				if cell.Error != "" {
+					col.Errors++
+				}
+				if cell.Usage != nil {
+					col.TotalTokens += int64(cell.Usage.TotalTokens)
+				}
+				if cell.Error == "" {
+					latency += float64(cell.LatencyMS)
+					if cell.Usage != nil {
+						promptTokens += float64(cell.Usage.PromptTokens)
+						responseTokens += float64(cell.Usage.ResponseTokens)
+					}
+					ok++
+				}
+			}
+			if ok > 0 {
+				col.MeanLatencyMS = latency / float64(ok)
+				col.MeanPromptTokens = promptTokens / float64(ok)
+				col.MeanResponseTokens = responseTokens / float64(ok)
+			}
+			mx.Columns = append(mx.Columns, col)
+		}
+	}
+}
+
+// heading names column v, m by what differs between the columns.
+func (mx *Matrix) heading(v, m int) string {
+	model := strings.TrimPrefix(mx.Models[m], "models/")
+	switch {
+	case len(mx.Models) == 1:
+		return mx.Variants[v].Label
+	case len(mx.Variants) == 1:
+		return model
+	}
+	return mx.Variants[v].Label + " · " + model
+}
+
+// WriteMarkdown renders the matrix as markdown: a summary per variant and
+// model, then a table per input with the outputs side by side.
+func (mx *Matrix) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Prompt comparison\n\n")
+	b.WriteString("| Prompt | Model | Errors | Mean latency | Mean prompt tokens | Mean response tokens | Total tokens |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, c := range mx.Columns {
+		fmt.Fprintf(&b, "| %s | %s | %d | %.0f ms | %.1f | %.1f | %d |\n",
+			c.Variant, strings.TrimPrefix(c.Model, "models/"), c.Errors, c.MeanLatencyMS, c.MeanPromptTokens, c.MeanResponseTokens, c.TotalTokens)
+	}
+
+	for i, in := range mx.Inputs {
+		fmt.Fprintf(&b, "\n## %s\n\n", in.Name)
+		for _, line := range strings.Split(strings.TrimRight(in.Text, "\n"), "\n") {
+			fmt.Fprintf(&b, "> %s\n", line)
+		}
+		var head, rule, latency, tokens, output strings.Builder
+		head.WriteString("\n| |")
+		rule.WriteString("|---|")
+		latency.WriteString("| Latency |")
+		tokens.WriteString("| Tokens |")
+		output.WriteString("| Output |")
+		for v := range mx.Variants {
+			for m := range mx.Models {
+				cell := mx.Cell(i, v, m)
+				fmt.Fprintf(&head, " %s |", tableCell(mx.heading(v, m)))
+				rule.WriteString("---|")
+				fmt.Fprintf(&latency, " %d ms |", cell.LatencyMS)
+				fmt.Fprintf(&tokens, " %s |", cell.tokens())
+				text := cell.Output
+				if cell.Error != "" {
+					text = "**error:** " + cell.Error
+				}
+				fmt.Fprintf(&output, " %s |", tableCell(text))
+			}
+		}
+		for _, row := range []*strings.Builder{&head, &rule, &latency, &tokens, &output} {
+			b.WriteString(row.String())
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tokens describes the usage of a cell as prompt → response tokens.
+func (c *Cell) tokens() string {
+	if c.Usage == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d → %d", c.Usage.PromptTokens, c.Usage.ResponseTokens)
+}
+
+// tableCell keeps text on one markdown table row.
+func tableCell(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "|", `\|`)
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// WriteHTML renders the matrix as a single HTML page without external
+// resources, with the outputs of each input side by side.
+func (mx *Matrix) WriteHTML(w io.Writer) error {
+	page := htmlComparison{Columns: mx.Columns}
+	for v := range mx.Variants {
+		for m := range mx.Models {
+			page.Headings = append(page.Headings, mx.heading(v, m))
+		}
+	}
+	for i, in := range mx.Inputs {
+		row := htmlInput{Input: in}
+		for v := range mx.Variants {
+			for m := range mx.Models {
+				row.Cells = append(row.Cells, mx.Cell(i, v, m))
+			}
+		}
+		page.Inputs = append(page.Inputs, row)
+	}
+	return comparisonTemplate.Execute(w, page)
+}
+
+type htmlInput struct {
+	Input Input
+	Cells []*Cell
+}
+
+type htmlComparison struct {
+	Columns  []Column
+	Headings []string
+	Inputs   []htmlInput
+}
+
+var comparisonTemplate = template.Must(template.New("comparison").Funcs(template.FuncMap{
+	"model": func(name string) string { return strings.TrimPrefix(name, "models/") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Prompt comparison</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; line-height: 1.5; color: #222; }
+table { border-collapse: collapse; font-size: 0.9rem; margin-bottom: 1rem; }
+th, td { text-align: left; padding: 0.3rem 0.8rem; vertical-align: top; border: 1px solid #ddd; }
+th { background: #f6f6f6; font-weight: 600; }
+.outputs { table-layout: fixed; width: 100%; }
+.text { white-space: pre-wrap; font-size: 0.85rem; }
+.error { color: #a00; font-style: italic; }
+.meta { color: #666; font-size: 0.8rem; }
+blockquote { white-space: pre-wrap; border-left: 3px solid #ddd; margin: 0 0 1rem; padding-left: 1rem; color: #444; }
+</style>
+</head>
+<body>
+<h1>Prompt comparison</h1>
+<table>
+<tr><th>Prompt</th><th>Model</th><th>Errors</th><th>Mean latency</th><th>Mean prompt tokens</th><th>Mean response tokens</th><th>Total tokens</th></tr>
+{{- range .Columns }}
+<tr><td>{{ .Variant }}</td><td>{{ model .Model }}</td><td>{{ .Errors }}</td><td>{{ printf "%.0f" .MeanLatencyMS }} ms</td><td>{{ printf "%.1f" .MeanPromptTokens }}</td><td>{{ printf "%.1f" .MeanResponseTokens }}</td><td>{{ .TotalTokens }}</td></tr>{{ end }}
+</table>
+{{ range .Inputs }}
+<section>
+<h2>{{ .Input.Name }}</h2>
+<blockquote>{{ .Input.Text }}</blockquote>
+<table class="outputs">
+<tr>{{ range $.Headings }}<th>{{ . }}</th>{{ end }}</tr>
+<tr>{{ range .Cells }}<td class="meta">{{ .LatencyMS }} ms{{ with .Usage }} · {{ .PromptTokens }} → {{ .ResponseTokens }} tokens{{ end }}{{ with .FinishReason }} · {{ . }}{{ end }}</td>{{ end }}</tr>
+<tr>{{ range .Cells }}<td>{{ if .Error }}<p class="error">{{ .Error }}</p>{{ else }}<div class="text">{{ .Output }}</div>{{ end }}</td>{{ end }}</tr>
+</table>
+</section>
+{{ end }}
+</body>
+</html>
+`))
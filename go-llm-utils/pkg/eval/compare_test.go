@@ -0,0 +1,130 @@
+package eval
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/geminitest"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func TestResolveVariants(t *testing.T) {
+	t.Parallel()
+
+	promptsDir, _ := project(t, assistantSuite)
+	writeFile(t, filepath.Join(promptsDir, "task-specific", "advisor", "gemini-pro2.5.md"), "Advise on {{ .topic }}.")
+	writeFile(t, filepath.Join(promptsDir, "task-specific", "advisor", "gpt4o.md"), "Advise.")
+	lib, err := prompts.LoadLibrary(promptsDir)
+	require.NoError(t, err)
+
+	variants, err := ResolveVariants(lib, []string{"task-specific/assistant@v1", "task-specific/assistant"})
+	require.NoError(t, err)
+	assert.Equal(t, []Variant{
+		{Label: "assistant@v1", File: filepath.Join("task-specific", "assistant", "v1.md")},
+		{Label: "assistant@v2", File: filepath.Join("task-specific", "assistant", "v2.md")},
+	}, variants)
+
+	variants, err = ResolveVariants(lib, []string{"task-specific/advisor/*"})
+	require.NoError(t, err)
+	assert.Equal(t, []Variant{
+		{Label: "gemini-pro2.5", File: filepath.Join("task-specific", "advisor", "gemini-pro2.5.md")},
+		{Label: "gpt4o", File: filepath.Join("task-specific", "advisor", "gpt4o.md")},
+	}, variants)
+
+	variants, err = ResolveVariants(lib, []string{"notes/draft.md"})
+	require.NoError(t, err)
+	assert.Equal(t, "notes/draft", variants[0].Label)
+	assert.True(t, filepath.IsAbs(variants[0].File), "files resolve against the working directory")
+
+	_, err = ResolveVariants(lib, []string{"task-specific/none/*"})
+	assert.EqualError(t, err, `no prompt matches "task-specific/none/*"`)
+	_, err = ResolveVariants(lib, []string{"task-specific/assistant@v3"})
+	assert.EqualError(t, err, `prompt "task-specific/assistant" has no version "v3"`)
+}
+
+func TestComparerRun(t *testing.T) {
+	t.Parallel()
+
+	promptsDir, _ := project(t, assistantSuite)
+	lib, err := prompts.LoadLibrary(promptsDir)
+	require.NoError(t, err)
+	variants, err := ResolveVariants(lib, []string{"task-specific/assistant@v1", "task-specific/assistant@v2"})
+	require.NoError(t, err)
+
+	gen := &geminitest.Generator{Fallback: func(c geminitest.GenerateCall) (*genai.GenerateContentResponse, error) {
+		if strings.Contains(c.Prompt(), "fail") {
+			return nil, assert.AnError
+		}
+		resp := geminitest.TextResponse(c.Model + " | " + c.Config.SystemInstruction.Parts[0].Text + "\n" + c.Prompt())
+		resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, CandidatesTokenCount: 4, TotalTokenCount: 14}
+		return resp, nil
+	}}
+	comparer := &Comparer{Generator: gen, Concurrency: 2}
+	comparer.PromptsDir = promptsDir
+	inputs := []Input{
+		{Name: "hello", Text: "Say hello.", Vars: prompts.Vars{"tone": "cheerful"}},
+		{Name: "broken", Text: "Please fail.", Vars: prompts.Vars{"tone": "grim"}},
+	}
+
+	mx, err := comparer.Run(context.Background(), variants, []string{"models/a", "models/b"}, inputs)
+	require.NoError(t, err)
+	require.Len(t, mx.Cells, 8)
+	assert.Len(t, gen.Calls(), 8)
+
+	cell := mx.Cell(0, 1, 0)
+	assert.Equal(t, "hello", cell.Input)
+	assert.Equal(t, "assistant@v2", cell.Variant)
+	assert.Equal(t, "models/a", cell.Model)
+	assert.Equal(t, "models/a | You are a cheerful assistant.\nSay hello.", cell.Output)
+	assert.Equal(t, int32(4), cell.Usage.ResponseTokens)
+	assert.Equal(t, "models/b", mx.Cell(0, 0, 1).Model)
+	assert.Contains(t, mx.Cell(1, 0, 0).Error, assert.AnError.Error())
+
+	require.Len(t, mx.Columns, 4)
+	col := mx.Columns[1]
+	assert.Equal(t, Column{Variant: "assistant@v1", Model: "models/b", Errors: 1, MeanLatencyMS: col.MeanLatencyMS,
+		MeanPromptTokens: 10, MeanResponseTokens: 4, TotalTokens: 14}, col)
+
+	var md strings.Builder
+	require.NoError(t, mx.WriteMarkdown(&md))
+	assert.Contains(t, md.String(), "| assistant@v1 | b | 1 | ")
+	assert.Contains(t, md.String(), "## hello\n\n> Say hello.\n")
+	assert.Contains(t, md.String(), "| | assistant@v1 · a | assistant@v1 · b | assistant@v2 · a | assistant@v2 · b |")
+	assert.Contains(t, md.String(), "| Tokens | 10 → 4 |")
+	assert.Contains(t, md.String(), "models/a \\| You are terse.<br>Say hello. |")
+	assert.Contains(t, md.String(), "**error:** failed to generate content")
+
+	var html strings.Builder
+	require.NoError(t, mx.WriteHTML(&html))
+	assert.Contains(t, html.String(), "<th>assistant@v2 · b</th>")
+	assert.Contains(t, html.String(), `<div class="text">models/b | You are a cheerful assistant.`)
+	assert.Contains(t, html.String(), `<p class="error">failed to generate content`)
+}
+
+func TestComparerDefaultModel(t *testing.T) {
+	t.Parallel()
+
+	comparer := &Comparer{Generator: &geminitest.Generator{Fallback: geminitest.Echo}}
+	comparer.Model = "models/chosen"
+	mx, err := comparer.Run(context.Background(), []Variant{{Label: "inline", File: writeTemp(t, "Be brief.")}}, nil,
+		[]Input{{Name: "one", Text: "Hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"models/chosen"}, mx.Models)
+	assert.Equal(t, "echo: Hi", mx.Cell(0, 0, 0).Output)
+
+	var md strings.Builder
+	require.NoError(t, mx.WriteMarkdown(&md))
+	assert.Contains(t, md.String(), "| | inline |", "a single model is not repeated in the headings")
+}
+
+func writeTemp(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "prompt.md")
+	writeFile(t, file, content)
+	return file
+}
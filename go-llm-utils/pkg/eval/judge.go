@@ -0,0 +1,454 @@
+//revive:disable:package-comments,exported
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"google.golang.org/genai"
+)
+
+// Judge scores outputs against a rubric with a judge model.
+type Judge struct {
+	Generator gemini.ContentGenerator
+	// Model is the judge model; it defaults to gemini.DefaultModel.
+	Model  string
+	Rubric *Rubric
+}
+
+// CriterionScore is the judge's score of one criterion.
+type CriterionScore struct {
+	Criterion string  `json:"criterion"`
+	Score     float64 `json:"score"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+// Scorecard is the judge's scores of one output.
+type Scorecard struct {
+	Scores []CriterionScore `json:"scores"`
+	// Overall is the mean of the scores weighted by the rubric.
+	Overall float64 `json:"overall"`
+}
+
+// Winners of a pairwise verdict.
+const (
+	WinnerA = "a"
+	WinnerB = "b"
+	Tie     = "tie"
+)
+
+// Verdict is the judge's preference between two outputs on one criterion.
+type Verdict struct {
+	Criterion string `json:"criterion"`
+	Winner    string `json:"winner"`
+	// Consistent is false when the judge picked a different winner once the
+	// outputs swapped places; such verdicts count as ties.
+	Consistent bool     `json:"consistent"`
+	Reasons    []string `json:"reasons,omitempty"`
+}
+
+const judgeInstructions = `You are an impartial judge of responses written by an AI assistant.
+Judge every response only by the criteria below. Do not favour a response
+for its length, its style or the order in which it is shown.
+
+Criteria:
+%s
+Scores are integers from %d (worst) to %d (best).
+`
+
+const scoreTemplate = `Score the response to the input below on every criterion.
+Answer with JSON only, in the form
+{"scores": [{"criterion": "<name>", "score": <integer>, "reason": "<one sentence>"}]}
+
+<input>
+%s
+</input>
+
+<response>
+%s
+</response>`
+
+const compareTemplate = `Compare the two responses to the input below on every criterion. The
+winner is "1" or "2", or "tie" when neither response is better.
+Answer with JSON only, in the form
+{"verdicts": [{"criterion": "<name>", "winner": "1" | "2" | "tie", "reason": "<one sentence>"}]}
+
+<input>
+%s
+</input>
+
+<response_1>
+%s
+</response_1>
+
+<response_2>
+%s
+</response_2>`
+
+// Score asks the judge to score the output produced for input.
+func (j *Judge) Score(ctx context.Context, input, output string) (*Scorecard, error) {
+	var answer struct {
+		Scores []CriterionScore `json:"scores"`
+	}
+	if err := j.ask(ctx, fmt.Sprintf(scoreTemplate, input, output), &answer); err != nil {
+		return nil, err
+	}
+
+	byName := map[string]CriterionScore{}
+	for _, s := range answer.Scores {
+		byName[s.Criterion] = s
+	}
+	card := &Scorecard{}
+	var total, weights float64
+	for _, c := range j.Rubric.Criteria {
+		s, ok := byName[c.Name]
+		if !ok {
+			return nil, fmt.Errorf("judge did not score criterion %q", c.Name)
+		}
+		if s.Score < float64(j.Rubric.Scale.Min) || s.Score > float64(j.Rubric.Scale.Max) {
+			return nil, fmt.Errorf("judge scored criterion %q %v, outside %d to %d", c.Name, s.Score, j.Rubric.Scale.Min, j.Rubric.Scale.Max)
+		}
+		card.Scores = append(card.Scores, s)
+		total += s.Score * c.Weight
+		weights += c.Weight
+	}
+	card.Overall = total / weights
+	return card, nil
+}
+
+// Compare asks the judge which of the outputs a and b for input is better
+// on each criterion. To cancel out the judge's bias towards the first or
+// second position, it asks twice with the outputs swapped and keeps only
+// the verdicts that agree.
+func (j *Judge) Compare(ctx context.Context, input, a, b string) ([]Verdict, error) {
+	first, err := j.compare(ctx, input, a, b)
+	if err != nil {
+		return nil, err
+	}
+	second, err := j.compare(ctx, input, b, a)
+	if err != nil {
+		return nil, err
+	}
+
+	verdicts := make([]Verdict, 0, len(j.Rubric.Criteria))
+	for _, c := range j.Rubric.Criteria {
+		x, y := first[c.Name], second[c.Name]
+		v := Verdict{Criterion: c.Name, Winner: x.winner, Consistent: x.winner == swap(y.winner)}
+		if !v.Consistent {
+			v.Winner = Tie
+		}
+		for _, reason := range []string{x.reason, y.reason} {
+			if reason != "" {
+				v.Reasons = append(v.Reasons, reason)
+			}
+		}
+		verdicts = append(verdicts, v)
+	}
+	return verdicts, nil
+}
+
+type pick struct {
+	winner, reason string
+}
+
+// compare asks for one verdict per criterion with first shown as response 1.
+func (j *Judge) compare(ctx context.Context, input, first, second string) (map[string]pick, error) {
+	var answer struct {
+		Verdicts []struct {
+			Criterion string `json:"criterion"`
+			Winner    string `json:"winner"`
+			Reason    string `json:"reason"`
+		} `json:"verdicts"`
+	}
+	if err := j.ask(ctx, fmt.Sprintf(compareTemplate, input, first, second), &answer); err != nil {
+		return nil, err
+	}
+
+	picks := map[string]pick{}
+	for _, v := range answer.Verdicts {
+		var winner string
+		switch strings.ToLower(strings.TrimSpace(v.Winner)) {
+		case "1":
+			winner = WinnerA
+		case "2":
+			winner = WinnerB
+		case Tie:
+			winner = Tie
+		default:
+			return nil, fmt.Errorf("judge picked an unknown winner %q for criterion %q", v.Winner, v.Criterion)
+		}
+		picks[v.Criterion] = pick{winner, v.Reason}
+	}
+	for _, c := range j.Rubric.Criteria {
+		if _, ok := picks[c.Name]; !ok {
+			return nil, fmt.Errorf("judge gave no verdict for criterion %q", c.Name)
+		}
+	}
+	return picks, nil
+}
+
+// swap maps a winner of the swapped comparison back to the original order.
+func swap(winner string) string {
+	switch winner {
+	case WinnerA:
+		return WinnerB
+	case WinnerB:
+		return WinnerA
+	}
+	return winner
+}
+
+// ask sends prompt to the judge model and decodes its JSON answer into v.
+func (j *Judge) ask(ctx context.Context, prompt string, v any) error {
+	model := j.Model
+	if model == "" {
+		model = gemini.DefaultModel
+	}
+	r := j.Rubric
+	system := fmt.Sprintf(judgeInstructions, r.describe(), r.Scale.Min, r.Scale.Max)
+	if r.Instructions != "" {
+		system += "\n" + strings.TrimSpace(r.Instructions) + "\n"
+	}
+	config := &genai.GenerateContentConfig{
+		Temperature:       gemini.F32(0),
+		ResponseMIMEType:  "application/json",
+		SystemInstruction: genai.NewContentFromText(system, genai.RoleUser),
+	}
+
+	resp, err := j.Generator.GenerateContent(ctx, model, genai.Text(prompt), config)
+	if err != nil {
+		return fmt.Errorf("failed to ask the judge: %w", err)
+	}
+	text, err := gemini.ResponseText(resp)
+	if err != nil {
+		return fmt.Errorf("failed to ask the judge: %w", err)
+	}
+	if err := json.Unmarshal([]byte(ExtractJSON(text)), v); err != nil {
+		return fmt.Errorf("failed to parse the judge's answer: %w", err)
+	}
+	return nil
+}
+
+// JudgedCase is the judgement of one case of a suite.
+type JudgedCase struct {
+	Name     string     `json:"name"`
+	A        *Scorecard `json:"a,omitempty"`
+	B        *Scorecard `json:"b,omitempty"`
+	Verdicts []Verdict  `json:"verdicts,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// CriterionSummary aggregates one criterion over the judged cases.
+type CriterionSummary struct {
+	Criterion string  `json:"criterion"`
+	Weight    float64 `json:"weight"`
+	MeanA     float64 `json:"mean_a"`
+	MeanB     float64 `json:"mean_b,omitempty"`
+	WinsA     int     `json:"wins_a,omitempty"`
+	WinsB     int     `json:"wins_b,omitempty"`
+	Ties      int     `json:"ties,omitempty"`
+	// Inconsistent counts the ties caused by position bias.
+	Inconsistent int `json:"inconsistent,omitempty"`
+}
+
+// Judgement is the judge's report on one suite run, or on two runs of the
+// same suite compared case by case.
+type Judgement struct {
+	Rubric   string             `json:"rubric"`
+	Judge    string             `json:"judge"`
+	Suite    string             `json:"suite"`
+	A        string             `json:"a"`
+	B        string             `json:"b,omitempty"`
+	Cases    []JudgedCase       `json:"cases"`
+	Criteria []CriterionSummary `json:"criteria"`
+	OverallA float64            `json:"overall_a"`
+	OverallB float64            `json:"overall_b,omitempty"`
+	// Judged is the number of cases that were scored.
+	Judged int `json:"judged"`
+}
+
+// Evaluate scores every case of report a and, when b is not nil, of report
+// b, and compares the two outputs of each case. Cases that failed to run
+// in either report are skipped.
+func (j *Judge) Evaluate(ctx context.Context, suite *Suite, a, b *Report) (*Judgement, error) {
+	model := j.Model
+	if model == "" {
+		model = gemini.DefaultModel
+	}
+	res := &Judgement{Rubric: j.Rubric.Name, Judge: model, Suite: suite.Path, A: label(a, b)}
+	var others map[string]CaseResult
+	if b != nil {
+		res.B = label(b, a)
+		others = map[string]CaseResult{}
+		for _, c := range b.Cases {
+			others[c.Name] = c
+		}
+	}
+	inputs := map[string]string{}
+	for _, c := range suite.Cases {
+		inputs[c.Name] = c.Input
+	}
+
+	for _, ca := range a.Cases {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		jc := JudgedCase{Name: ca.Name}
+		cb, ok := others[ca.Name]
+		switch {
+		case ca.Error != "":
+			jc.Error = ca.Error
+		case b != nil && !ok:
+			jc.Error = "not run for " + res.B
+		case b != nil && cb.Error != "":
+			jc.Error = cb.Error
+		default:
+			if err := j.judgeCase(ctx, inputs[ca.Name], ca, cb, b != nil, &jc); err != nil {
+				jc.Error = err.Error()
+			}
+		}
+		res.Cases = append(res.Cases, jc)
+	}
+	res.summarize(j.Rubric)
+	return res, nil
+}
+
+func (j *Judge) judgeCase(ctx context.Context, input string, a, b CaseResult, pair bool, jc *JudgedCase) error {
+	var err error
+	if jc.A, err = j.Score(ctx, input, a.Output); err != nil {
+		return err
+	}
+	if !pair {
+		return nil
+	}
+	if jc.B, err = j.Score(ctx, input, b.Output); err != nil {
+		return err
+	}
+	jc.Verdicts, err = j.Compare(ctx, input, a.Output, b.Output)
+	return err
+}
+
+// label names a report by its version, adding the model when the other
+// report has the same version.
+func label(r, other *Report) string {
+	name := r.Version
+	if name == "" {
+		name = r.Prompt
+	}
+	if other != nil && other.Version == r.Version {
+		name += " on " + r.Model
+	}
+	return name
+}
+
+func (res *Judgement) summarize(rubric *Rubric) {
+	var weights float64
+	for i, c := range rubric.Criteria {
+		s := CriterionSummary{Criterion: c.Name, Weight: c.Weight}
+		var sumA, sumB float64
+		n := 0
+		for _, jc := range res.Cases {
+			if jc.Error != "" || jc.A == nil {
+				continue
+			}
+			n++
+			sumA += jc.A.Scores[i].Score
+			if jc.B != nil {
+				sumB += jc.B.Scores[i].Score
+			}
+			if len(jc.Verdicts) > 0 {
+				v := jc.Verdicts[i]
+				switch v.Winner {
+				case WinnerA:
+					s.WinsA++
+				case WinnerB:
+					s.WinsB++
+				default:
+					s.Ties++
+				}
+				if !v.Consistent {
+					s.Inconsistent++
+				}
+			}
+		}
+		res.Judged = n
+		if n > 0 {
+			s.MeanA = sumA / float64(n)
+			s.MeanB = sumB / float64(n)
+		}
+		res.Criteria = append(res.Criteria, s)
+		res.OverallA += s.MeanA * c.Weight
+		res.OverallB += s.MeanB * c.Weight
+		weights += c.Weight
+	}
+	res.OverallA /= weights
+	res.OverallB /= weights
+}
+
+// Print writes the judgement as a markdown report: a table of the mean
+// scores and pairwise wins per criterion, followed by the scores of each case.
+func (res *Judgement) Print(w io.Writer) {
+	fmt.Fprintf(w, "# %s: %s\n\n", res.Suite, res.Rubric)
+	fmt.Fprintf(w, "Judged %d of %d cases with %s.\n\n", res.Judged, len(res.Cases), res.Judge)
+
+	if res.B == "" {
+		fmt.Fprintf(w, "| Criterion | Weight | %s |\n|---|---|---|\n", res.A)
+		for _, s := range res.Criteria {
+			fmt.Fprintf(w, "| %s | %g | %.2f |\n", s.Criterion, s.Weight, s.MeanA)
+		}
+		fmt.Fprintf(w, "| **overall** | | **%.2f** |\n", res.OverallA)
+	} else {
+		fmt.Fprintf(w, "| Criterion | Weight | %s | %s | %s wins | %s wins | Ties | Inconsistent |\n", res.A, res.B, res.A, res.B)
+		fmt.Fprintln(w, "|---|---|---|---|---|---|---|---|")
+		for _, s := range res.Criteria {
+			fmt.Fprintf(w, "| %s | %g | %.2f | %.2f | %d | %d | %d | %d |\n",
+				s.Criterion, s.Weight, s.MeanA, s.MeanB, s.WinsA, s.WinsB, s.Ties, s.Inconsistent)
+		}
+		fmt.Fprintf(w, "| **overall** | | **%.2f** | **%.2f** | | | | |\n", res.OverallA, res.OverallB)
+		fmt.Fprintf(w, "\nInconsistent verdicts changed with the order of the responses and count as ties.\n")
+	}
+
+	fmt.Fprintln(w, "\n## Cases")
+	for _, jc := range res.Cases {
+		fmt.Fprintf(w, "\n### %s\n\n", jc.Name)
+		if jc.Error != "" {
+			fmt.Fprintf(w, "Not judged: %s\n", jc.Error)
+			continue
+		}
+		for i, s := range jc.A.Scores {
+			fmt.Fprintf(w, "- %s: %s %s", s.Criterion, res.A, formatScore(s.Score))
+			if jc.B != nil {
+				fmt.Fprintf(w, ", %s %s", res.B, formatScore(jc.B.Scores[i].Score))
+			}
+			if len(jc.Verdicts) > 0 {
+				fmt.Fprintf(w, ", winner: %s", res.winner(jc.Verdicts[i]))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// winner names the winner of a verdict by its report label.
+func (res *Judgement) winner(v Verdict) string {
+	switch {
+	case v.Winner == WinnerA:
+		return res.A
+	case v.Winner == WinnerB:
+		return res.B
+	case !v.Consistent:
+		return "tie (inconsistent)"
+	}
+	return Tie
+}
+
+func formatScore(score float64) string {
+	if score == math.Trunc(score) {
+		return fmt.Sprintf("%.0f", score)
+	}
+	return fmt.Sprintf("%.1f", score)
+}
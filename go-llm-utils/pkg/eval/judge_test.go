@@ -0,0 +1,192 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/geminitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+const qualityRubric = `criteria:
+  - name: correctness
+    description: The answer is correct.
+    weight: 3
+  - name: clarity
+    description: The answer is clear.
+instructions: Prefer short answers.
+`
+
+func rubric(t *testing.T) *Rubric {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, RubricsDir, "quality.yaml"), qualityRubric)
+	r, err := LoadRubric(RubricFile(dir, "quality"))
+	require.NoError(t, err)
+	return r
+}
+
+func TestLoadRubric(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, RubricsDir, "quality.yaml"), qualityRubric)
+	names, err := Rubrics(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"quality"}, names)
+
+	r, err := LoadRubric(RubricFile(dir, "quality"))
+	require.NoError(t, err)
+	assert.Equal(t, "quality", r.Name, "named after the file by default")
+	assert.Equal(t, Scale{Min: 1, Max: 5}, r.Scale)
+	assert.Equal(t, []float64{3, 1}, []float64{r.Criteria[0].Weight, r.Criteria[1].Weight})
+	assert.Equal(t, "other/rubric.yaml", RubricFile(dir, "other/rubric.yaml"))
+
+	for _, tc := range []struct {
+		name, rubric, err string
+	}{
+		{"no criteria", "name: x", "has no criteria"},
+		{"bad scale", "scale: {min: 5, max: 1}\ncriteria: [{name: a, description: b}]", "scale minimum 5 must be below the maximum 1"},
+		{"no description", "criteria: [{name: a}]", "criterion 1 needs a name and a description"},
+		{"duplicate", "criteria: [{name: a, description: b}, {name: a, description: c}]", `criterion "a" is defined twice`},
+		{"negative weight", "criteria: [{name: a, description: b, weight: -1}]", `criterion "a" has a negative weight`},
+		{"unknown field", "criteria: [{name: a, description: b, points: 3}]", "field points not found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			file := filepath.Join(t.TempDir(), "rubric.yaml")
+			writeFile(t, file, tc.rubric)
+			_, err := LoadRubric(file)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestJudgeScore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	gen := geminitest.NewGenerator(
+		"```json\n"+`{"scores": [{"criterion": "clarity", "score": 2}, {"criterion": "correctness", "score": 4, "reason": "Right."}]}`+"\n```",
+		`{"scores": [{"criterion": "correctness", "score": 6}, {"criterion": "clarity", "score": 2}]}`,
+		`{"scores": [{"criterion": "correctness", "score": 3}]}`,
+		`not json`,
+	)
+	judge := &Judge{Generator: gen, Model: "models/judge", Rubric: rubric(t)}
+
+	card, err := judge.Score(ctx, "What is 2+2?", "4")
+	require.NoError(t, err)
+	assert.Equal(t, []CriterionScore{{"correctness", 4, "Right."}, {"clarity", 2, ""}}, card.Scores, "in rubric order")
+	assert.InDelta(t, 3.5, card.Overall, 1e-9, "weighted by the rubric")
+
+	call := gen.Calls()[0]
+	assert.Equal(t, "models/judge", call.Model)
+	assert.Equal(t, "application/json", call.Config.ResponseMIMEType)
+	assert.Equal(t, float32(0), *call.Config.Temperature)
+	system := call.Config.SystemInstruction.Parts[0].Text
+	assert.Contains(t, system, "- correctness: The answer is correct.\n")
+	assert.Contains(t, system, "from 1 (worst) to 5 (best)")
+	assert.Contains(t, system, "Prefer short answers.")
+	assert.Contains(t, call.Prompt(), "<input>\nWhat is 2+2?\n</input>")
+	assert.Contains(t, call.Prompt(), "<response>\n4\n</response>")
+
+	_, err = judge.Score(ctx, "q", "a")
+	assert.EqualError(t, err, `judge scored criterion "correctness" 6, outside 1 to 5`)
+	_, err = judge.Score(ctx, "q", "a")
+	assert.EqualError(t, err, `judge did not score criterion "clarity"`)
+	_, err = judge.Score(ctx, "q", "a")
+	assert.ErrorContains(t, err, "failed to parse the judge's answer")
+}
+
+// biasedJudge scores outputs by whether they say "good", prefers the good
+// response on correctness and always prefers the first response on clarity.
+func biasedJudge(c geminitest.GenerateCall) (*genai.GenerateContentResponse, error) {
+	prompt := c.Prompt()
+	if strings.Contains(prompt, "<response>") {
+		score := 2
+		if strings.Contains(prompt, "good") {
+			score = 5
+		}
+		return geminitest.TextResponse(fmt.Sprintf(
+			`{"scores": [{"criterion": "correctness", "score": %d}, {"criterion": "clarity", "score": 4}]}`, score)), nil
+	}
+	first := prompt[strings.Index(prompt, "<response_1>"):strings.Index(prompt, "<response_2>")]
+	winner := "2"
+	if strings.Contains(first, "good") {
+		winner = "1"
+	}
+	return geminitest.TextResponse(fmt.Sprintf(
+		`{"verdicts": [{"criterion": "correctness", "winner": %q, "reason": "More correct."}, {"criterion": "clarity", "winner": "1"}]}`, winner)), nil
+}
+
+func TestJudgeCompare(t *testing.T) {
+	t.Parallel()
+
+	gen := &geminitest.Generator{Fallback: biasedJudge}
+	judge := &Judge{Generator: gen, Rubric: rubric(t)}
+	verdicts, err := judge.Compare(context.Background(), "q", "bad answer", "good answer")
+	require.NoError(t, err)
+	assert.Equal(t, []Verdict{
+		{Criterion: "correctness", Winner: WinnerB, Consistent: true, Reasons: []string{"More correct.", "More correct."}},
+		{Criterion: "clarity", Winner: Tie, Consistent: false},
+	}, verdicts)
+
+	require.True(t, gen.AssertCalls(t, 2))
+	calls := gen.Calls()
+	assert.Equal(t, "models/gemini-2.0-flash", calls[0].Model, "the default model")
+	assert.Less(t, strings.Index(calls[0].Prompt(), "bad answer"), strings.Index(calls[0].Prompt(), "good answer"))
+	assert.Less(t, strings.Index(calls[1].Prompt(), "good answer"), strings.Index(calls[1].Prompt(), "bad answer"), "swapped")
+
+	judge.Generator = geminitest.NewGenerator(`{"verdicts": [{"criterion": "correctness", "winner": "A"}]}`)
+	_, err = judge.Compare(context.Background(), "q", "a", "b")
+	assert.EqualError(t, err, `judge picked an unknown winner "A" for criterion "correctness"`)
+}
+
+func TestJudgeEvaluate(t *testing.T) {
+	t.Parallel()
+
+	suite := &Suite{Path: "evals/assistant.yaml", Cases: []Case{
+		{Name: "one", Input: "first"}, {Name: "two", Input: "second"}, {Name: "three", Input: "third"},
+	}}
+	a := &Report{Version: "v1", Model: "m", Cases: []CaseResult{
+		{Name: "one", Output: "good"}, {Name: "two", Output: "bad"}, {Name: "three", Output: "good"},
+	}}
+	b := &Report{Version: "v2", Model: "m", Cases: []CaseResult{
+		{Name: "one", Output: "good too"}, {Name: "two", Output: "good"}, {Name: "three", Error: "quota"},
+	}}
+	judge := &Judge{Generator: &geminitest.Generator{Fallback: biasedJudge}, Model: "models/judge", Rubric: rubric(t)}
+
+	res, err := judge.Evaluate(context.Background(), suite, a, b)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", res.A)
+	assert.Equal(t, "v2", res.B)
+	assert.Equal(t, 2, res.Judged)
+	assert.Equal(t, "quota", res.Cases[2].Error)
+	assert.Equal(t, CriterionSummary{Criterion: "correctness", Weight: 3, MeanA: 3.5, MeanB: 5, WinsB: 1, Ties: 1, Inconsistent: 1}, res.Criteria[0], "both are good, so the first is always picked")
+	assert.Equal(t, CriterionSummary{Criterion: "clarity", Weight: 1, MeanA: 4, MeanB: 4, Ties: 2, Inconsistent: 2}, res.Criteria[1])
+	assert.InDelta(t, (3.5*3+4)/4, res.OverallA, 1e-9)
+	assert.InDelta(t, (5*3+4)/4.0, res.OverallB, 1e-9)
+
+	var out strings.Builder
+	res.Print(&out)
+	assert.Contains(t, out.String(), "Judged 2 of 3 cases with models/judge.")
+	assert.Contains(t, out.String(), "| correctness | 3 | 3.50 | 5.00 | 0 | 1 | 1 | 1 |")
+	assert.Contains(t, out.String(), "- correctness: v1 2, v2 5, winner: v2\n")
+	assert.Contains(t, out.String(), "- clarity: v1 4, v2 4, winner: tie (inconsistent)\n")
+	assert.Contains(t, out.String(), "Not judged: quota")
+
+	// A single report is only scored.
+	res, err = judge.Evaluate(context.Background(), suite, a, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Judged)
+	assert.Nil(t, res.Cases[0].Verdicts)
+	assert.InDelta(t, 4, res.Criteria[0].MeanA, 1e-9)
+	out.Reset()
+	res.Print(&out)
+	assert.Contains(t, out.String(), "| **overall** | | **4.00** |")
+}
@@ -0,0 +1,129 @@
+//revive:disable:package-comments,exported
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RubricsDir holds the rubric files, relative to the prompts directory.
+const RubricsDir = "rubrics"
+
+// Rubric tells a judge model how to score responses.
+//
+//	name: assistant-quality
+//	scale: {min: 1, max: 5}
+//	criteria:
+//	  - name: correctness
+//	    description: The answer is technically correct.
+//	    weight: 2
+//	  - name: clarity
+//	    description: The answer is easy to follow.
+//	instructions: Penalize answers that ignore the system prompt.
+type Rubric struct {
+	Name         string      `yaml:"name" json:"name"`
+	Description  string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Scale        Scale       `yaml:"scale,omitempty" json:"scale"`
+	Criteria     []Criterion `yaml:"criteria" json:"criteria"`
+	Instructions string      `yaml:"instructions,omitempty" json:"instructions,omitempty"`
+}
+
+// Scale is the range of scores; it defaults to 1 to 5.
+type Scale struct {
+	Min int `yaml:"min" json:"min"`
+	Max int `yaml:"max" json:"max"`
+}
+
+// Criterion is one aspect a response is scored on. Weights default to 1
+// and only matter for the overall score.
+type Criterion struct {
+	Name        string  `yaml:"name" json:"name"`
+	Description string  `yaml:"description" json:"description"`
+	Weight      float64 `yaml:"weight,omitempty" json:"weight,omitempty"`
+}
+
+// LoadRubric reads a rubric file and fills in its defaults.
+func LoadRubric(filename string) (*Rubric, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var r Rubric
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to parse rubric %q: %w", filename, err)
+	}
+	if r.Name == "" {
+		r.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("rubric %q: %w", filename, err)
+	}
+	return &r, nil
+}
+
+// RubricFile resolves a rubric given by name, looked up in the rubrics
+// directory of promptsDir, or by path.
+func RubricFile(promptsDir, name string) string {
+	if strings.ContainsAny(name, `/\`) || filepath.Ext(name) != "" {
+		return name
+	}
+	return filepath.Join(promptsDir, RubricsDir, name+".yaml")
+}
+
+// Rubrics returns the names of the rubrics in the rubrics directory of promptsDir.
+func Rubrics(promptsDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(promptsDir, RubricsDir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".yaml"))
+	}
+	return names, nil
+}
+
+func (r *Rubric) validate() error {
+	if r.Scale == (Scale{}) {
+		r.Scale = Scale{Min: 1, Max: 5}
+	}
+	if r.Scale.Min >= r.Scale.Max {
+		return fmt.Errorf("scale minimum %d must be below the maximum %d", r.Scale.Min, r.Scale.Max)
+	}
+	if len(r.Criteria) == 0 {
+		return fmt.Errorf("has no criteria")
+	}
+	seen := map[string]bool{}
+	for i := range r.Criteria {
+		c := &r.Criteria[i]
+		if c.Name == "" || c.Description == "" {
+			return fmt.Errorf("criterion %d needs a name and a description", i+1)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("criterion %q is defined twice", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Weight < 0 {
+			return fmt.Errorf("criterion %q has a negative weight", c.Name)
+		}
+		if c.Weight == 0 {
+			c.Weight = 1
+		}
+	}
+	return nil
+}
+
+// describe lists the criteria for the judge prompt.
+func (r *Rubric) describe() string {
+	var b strings.Builder
+	for _, c := range r.Criteria {
+		fmt.Fprintf(&b, "- %s: %s\n", c.Name, c.Description)
+	}
+	return b.String()
+}
@@ -0,0 +1,284 @@
+//revive:disable:package-comments,exported
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/batch"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"google.golang.org/genai"
+)
+
+// GoldenMode says what a run does with the stored golden outputs.
+type GoldenMode int
+
+const (
+	// GoldenReport diffs outputs against their golden files without failing cases.
+	GoldenReport GoldenMode = iota
+	// GoldenStrict fails cases whose output differs from, or has no, golden file.
+	GoldenStrict
+	// GoldenUpdate stores the outputs of passing cases as the new golden files.
+	GoldenUpdate
+)
+
+var goldenModes = map[string]GoldenMode{
+	"report": GoldenReport,
+	"strict": GoldenStrict,
+	"update": GoldenUpdate,
+}
+
+// GoldenModes lists the names accepted by ParseGoldenMode.
+func GoldenModes() []string {
+	return []string{"report", "strict", "update"}
+}
+
+// ParseGoldenMode returns the mode with the given name.
+func ParseGoldenMode(name string) (GoldenMode, error) {
+	mode, ok := goldenModes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown golden mode %q (available: %s)", name, strings.Join(GoldenModes(), ", "))
+	}
+	return mode, nil
+}
+
+// Runner executes suites against a content generator.
+type Runner struct {
+	// Settings resolve the profile, model and prompt files. The suite's
+	// model and profile apply when Settings.Model and Settings.Profile are empty.
+	batch.Settings
+	Generator gemini.ContentGenerator
+	// Library resolves prompt IDs; it is loaded from Settings.PromptsDir if nil.
+	Library *prompts.Library
+	Golden  GoldenMode
+}
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	Name      string       `json:"name"`
+	Output    string       `json:"output,omitempty"`
+	Failures  []string     `json:"failures,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Golden    string       `json:"golden,omitempty"`
+	Diff      string       `json:"diff,omitempty"`
+	Usage     *batch.Usage `json:"usage,omitempty"`
+	LatencyMS int64        `json:"latency_ms"`
+}
+
+// Golden states of a case result.
+const (
+	GoldenMatch   = "match"
+	GoldenChanged = "changed"
+	GoldenMissing = "missing"
+	GoldenUpdated = "updated"
+)
+
+// Passed reports whether the case produced an output that met every assertion.
+func (r CaseResult) Passed() bool {
+	return r.Error == "" && len(r.Failures) == 0
+}
+
+// Report is the outcome of a suite run.
+type Report struct {
+	Suite   string       `json:"suite"`
+	Prompt  string       `json:"prompt"`
+	Version string       `json:"version,omitempty"`
+	Model   string       `json:"model"`
+	Cases   []CaseResult `json:"cases"`
+}
+
+// Failed returns the number of cases that did not pass.
+func (r *Report) Failed() int {
+	failed := 0
+	for _, c := range r.Cases {
+		if !c.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Print writes a PASS or FAIL line per case with the failed assertions and
+// golden diffs, followed by a summary.
+func (r *Report) Print(w io.Writer) {
+	name := r.Prompt
+	if r.Version != "" {
+		name += "@" + r.Version
+	}
+	fmt.Fprintf(w, "%s: %s on %s\n", r.Suite, name, r.Model)
+	for _, c := range r.Cases {
+		status := "PASS"
+		if !c.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s %s (%dms)", status, c.Name, c.LatencyMS)
+		if c.Golden != "" && c.Golden != GoldenMatch {
+			fmt.Fprintf(w, " [golden %s]", c.Golden)
+		}
+		fmt.Fprintln(w)
+		if c.Error != "" {
+			fmt.Fprintf(w, "    error: %s\n", c.Error)
+		}
+		for _, f := range c.Failures {
+			fmt.Fprintf(w, "    %s\n", f)
+		}
+		if c.Diff != "" {
+			for _, line := range strings.Split(strings.TrimRight(c.Diff, "\n"), "\n") {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintf(w, "%d passed, %d failed\n", len(r.Cases)-r.Failed(), r.Failed())
+}
+
+// Run executes every case of the suite. version overrides the suite's
+// prompt version when not empty. Failures of single cases are recorded in
+// the report; the error is for problems that stop the whole run.
+func (r *Runner) Run(ctx context.Context, suite *Suite, version string) (*Report, error) {
+	promptFile, resolved, err := r.promptFile(suite, version)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := r.Settings
+	if settings.Profile == "" {
+		settings.Profile = suite.Profile
+	}
+	if settings.Model == "" {
+		settings.Model = suite.Model
+	}
+
+	report := &Report{Suite: suite.Path, Prompt: suite.Prompt, Version: resolved}
+	if suite.Prompt == "" {
+		report.Prompt = suite.PromptFile
+	}
+	for _, c := range suite.Cases {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		req := batch.Request{ID: c.Name, Prompt: c.Input, SystemFile: promptFile, Vars: suite.vars(c)}
+		model, contents, config, err := settings.Prepare(req)
+		if err != nil {
+			return report, fmt.Errorf("case %q: %w", c.Name, err)
+		}
+		report.Model = model
+
+		res := r.runCase(ctx, model, contents, config, c, filepath.Dir(suite.Path))
+		if res.Error == "" {
+			if err := r.golden(suite, c, &res); err != nil {
+				return report, err
+			}
+		}
+		report.Cases = append(report.Cases, res)
+	}
+	return report, nil
+}
+
+// promptFile resolves the suite's prompt to a file relative to the prompts
+// directory, and the version it is.
+func (r *Runner) promptFile(suite *Suite, version string) (string, string, error) {
+	if suite.PromptFile != "" {
+		if version != "" {
+			return "", "", fmt.Errorf("suite %q names a prompt file, which has no versions", suite.Path)
+		}
+		return suite.PromptFile, "", nil
+	}
+	lib := r.Library
+	if lib == nil {
+		var err error
+		if lib, err = prompts.LoadLibrary(r.PromptsDir); err != nil {
+			return "", "", err
+		}
+	}
+	if version == "" {
+		version = suite.Version
+	}
+	p, err := lib.Get(suite.Prompt, version)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.FromSlash(p.Path), p.Version, nil
+}
+
+func (r *Runner) runCase(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig, c Case, dir string) CaseResult {
+	res := CaseResult{Name: c.Name}
+
+	start := time.Now()
+	resp, err := r.Generator.GenerateContent(ctx, model, contents, config)
+	res.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = fmt.Sprintf("failed to generate content: %v", err)
+		return res
+	}
+	if resp.UsageMetadata != nil {
+		res.Usage = &batch.Usage{
+			PromptTokens:   resp.UsageMetadata.PromptTokenCount,
+			ResponseTokens: resp.UsageMetadata.CandidatesTokenCount,
+			ThoughtsTokens: resp.UsageMetadata.ThoughtsTokenCount,
+			TotalTokens:    resp.UsageMetadata.TotalTokenCount,
+		}
+	}
+	text, err := gemini.ResponseText(resp)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Output = text
+	for _, a := range c.Assertions {
+		if failure := a.Check(text, dir); failure != "" {
+			res.Failures = append(res.Failures, failure)
+		}
+	}
+	return res
+}
+
+// golden compares the output with the golden file of the case, or updates it.
+func (r *Runner) golden(suite *Suite, c Case, res *CaseResult) error {
+	filename := suite.GoldenFile(c)
+
+	if r.Golden == GoldenUpdate {
+		if !res.Passed() {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return fmt.Errorf("failed to create golden directory: %w", err)
+		}
+		if _, err := gemini.WriteFile(filename, []byte(res.Output), gemini.FileOptions{}); err != nil {
+			return fmt.Errorf("failed to update golden output: %w", err)
+		}
+		res.Golden = GoldenUpdated
+		return nil
+	}
+
+	data, err := os.ReadFile(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		res.Golden = GoldenMissing
+		if r.Golden == GoldenStrict {
+			res.Failures = append(res.Failures, "no golden output in "+filename)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to read golden output: %w", err)
+	}
+
+	res.Diff = prompts.UnifiedDiff("golden/"+c.Name, "output/"+c.Name, string(data), res.Output)
+	if res.Diff == "" {
+		res.Golden = GoldenMatch
+		return nil
+	}
+	res.Golden = GoldenChanged
+	if r.Golden == GoldenStrict {
+		res.Failures = append(res.Failures, "output differs from "+filename)
+	}
+	return nil
+}
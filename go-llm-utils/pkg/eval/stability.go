@@ -0,0 +1,387 @@
+//revive:disable:package-comments,exported
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/batch"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/search"
+)
+
+// Default thresholds beyond which a prompt counts as unstable.
+const (
+	DefaultMaxEditDistance = 0.4
+	DefaultMinSimilarity   = 0.9
+	// DefaultRuns is the number of runs per input and temperature.
+	DefaultRuns = 5
+)
+
+// StabilityRunner sends the same requests several times with different
+// seeds, or the same seed, at one or more temperatures and measures how
+// much the outputs vary.
+type StabilityRunner struct {
+	// Settings resolve the profile, model and prompt files.
+	batch.Settings
+	Generator gemini.ContentGenerator
+	// Embedder adds the embedding similarity of the outputs; it is skipped if nil.
+	Embedder    search.Embedder
+	Concurrency int
+	// Runs defaults to DefaultRuns.
+	Runs int
+	// Seed is the seed of the first run; later runs count up from it
+	// unless FixedSeed is set, which measures what a fixed seed leaves
+	// to chance.
+	Seed      int32
+	FixedSeed bool
+	// Temperatures default to the profile's temperature.
+	Temperatures []float32
+	// MaxEditDistance and MinSimilarity default to DefaultMaxEditDistance
+	// and DefaultMinSimilarity when nil; zero is a valid threshold.
+	MaxEditDistance *float64
+	MinSimilarity   *float64
+}
+
+// Sample is the output of one run.
+type Sample struct {
+	Seed   int32  `json:"seed"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// StabilityGroup measures the runs of one input with one variant at one
+// temperature. The rates and distances are means over every pair of
+// outputs; edit distances count words and are normalized by the longer output.
+type StabilityGroup struct {
+	Variant        string   `json:"variant"`
+	Input          string   `json:"input"`
+	Temperature    *float32 `json:"temperature,omitempty"`
+	Samples        []Sample `json:"samples"`
+	Distinct       int      `json:"distinct"`
+	ExactMatchRate float64  `json:"exact_match_rate"`
+	EditDistance   float64  `json:"edit_distance"`
+	Similarity     *float64 `json:"similarity,omitempty"`
+	Unstable       bool     `json:"unstable"`
+	Reasons        []string `json:"reasons,omitempty"`
+	// Error is set when fewer than two runs produced an output.
+	Error string `json:"error,omitempty"`
+}
+
+// PromptStability averages the groups of one variant.
+type PromptStability struct {
+	Variant        string   `json:"variant"`
+	Groups         int      `json:"groups"`
+	Unstable       int      `json:"unstable"`
+	ExactMatchRate float64  `json:"exact_match_rate"`
+	EditDistance   float64  `json:"edit_distance"`
+	Similarity     *float64 `json:"similarity,omitempty"`
+}
+
+// StabilityReport is the outcome of a stability run.
+type StabilityReport struct {
+	Model           string            `json:"model"`
+	Runs            int               `json:"runs"`
+	Seeds           []int32           `json:"seeds"`
+	MaxEditDistance float64           `json:"max_edit_distance"`
+	MinSimilarity   float64           `json:"min_similarity"`
+	Groups          []StabilityGroup  `json:"groups"`
+	Prompts         []PromptStability `json:"prompts"`
+}
+
+// Run executes every input with every variant Runs times per temperature.
+// Failed runs are recorded in their samples; the error is for problems
+// that stop the whole run.
+func (r *StabilityRunner) Run(ctx context.Context, variants []Variant, inputs []Input) (*StabilityReport, error) {
+	runs := r.Runs
+	if runs <= 0 {
+		runs = DefaultRuns
+	}
+	report := &StabilityReport{
+		Runs:            runs,
+		MaxEditDistance: valueOr(r.MaxEditDistance, DefaultMaxEditDistance),
+		MinSimilarity:   valueOr(r.MinSimilarity, DefaultMinSimilarity),
+	}
+	for k := range runs {
+		seed := r.Seed
+		if !r.FixedSeed {
+			seed += int32(k)
+		}
+		report.Seeds = append(report.Seeds, seed)
+	}
+
+	model, _, config, err := r.Prepare(batch.Request{})
+	if err != nil {
+		return nil, err
+	}
+	report.Model = model
+	temperatures := []*float32{config.Temperature}
+	if len(r.Temperatures) > 0 {
+		temperatures = nil
+		for _, t := range r.Temperatures {
+			temperatures = append(temperatures, gemini.F32(t))
+		}
+	}
+
+	var requests []batch.Request
+	for _, variant := range variants {
+		for _, in := range inputs {
+			for _, temperature := range temperatures {
+				g := len(report.Groups)
+				report.Groups = append(report.Groups, StabilityGroup{Variant: variant.Label, Input: in.Name, Temperature: temperature})
+				for k, seed := range report.Seeds {
+					requests = append(requests, batch.Request{
+						ID: fmt.Sprintf("%d.%d", g, k), Prompt: in.Text, SystemFile: variant.File, Vars: in.Vars,
+						Seed: gemini.I32(seed), Temperature: temperature,
+					})
+				}
+			}
+		}
+	}
+
+	runner := &batch.Runner{Settings: r.Settings, Generator: r.Generator, Concurrency: r.Concurrency}
+	var buf bytes.Buffer
+	if _, err := runner.Run(ctx, requests, &buf); err != nil {
+		return nil, err
+	}
+	results := map[string]batch.Result{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var res batch.Result
+		if err := dec.Decode(&res); err != nil {
+			return nil, fmt.Errorf("failed to read stability results: %w", err)
+		}
+		results[res.ID] = res
+	}
+	for g := range report.Groups {
+		group := &report.Groups[g]
+		for k, seed := range report.Seeds {
+			s := Sample{Seed: seed, Error: "not run"}
+			if res, ok := results[fmt.Sprintf("%d.%d", g, k)]; ok {
+				s.Output, s.Error = res.Text, res.Error
+			}
+			group.Samples = append(group.Samples, s)
+		}
+	}
+
+	if err := r.measure(ctx, report); err != nil {
+		return nil, err
+	}
+	report.summarize()
+	return report, nil
+}
+
+// measure computes the metrics of every group and flags the unstable ones.
+func (r *StabilityRunner) measure(ctx context.Context, report *StabilityReport) error {
+	outputs := make([][]string, len(report.Groups))
+	var texts []string
+	for g := range report.Groups {
+		for _, s := range report.Groups[g].Samples {
+			if s.Error == "" {
+				outputs[g] = append(outputs[g], s.Output)
+			}
+		}
+		if len(outputs[g]) >= 2 {
+			texts = append(texts, outputs[g]...)
+		}
+	}
+
+	var vectors [][]float32
+	if r.Embedder != nil && len(texts) > 0 {
+		var err error
+		if vectors, err = r.Embedder.EmbedDocuments(ctx, texts); err != nil {
+			return err
+		}
+	}
+
+	for g := range report.Groups {
+		group := &report.Groups[g]
+		out := outputs[g]
+		if len(out) < 2 {
+			group.Error = fmt.Sprintf("needs at least two outputs, got %d", len(out))
+			continue
+		}
+
+		distinct := map[string]bool{}
+		var matches, distance, similarity float64
+		pairs := 0
+		for i := range out {
+			distinct[out[i]] = true
+			for j := i + 1; j < len(out); j++ {
+				pairs++
+				if out[i] == out[j] {
+					matches++
+				}
+				distance += EditDistance(out[i], out[j])
+				if vectors != nil {
+					similarity += cosine(vectors[i], vectors[j])
+				}
+			}
+		}
+		group.Distinct = len(distinct)
+		group.ExactMatchRate = matches / float64(pairs)
+		group.EditDistance = distance / float64(pairs)
+		if group.EditDistance > report.MaxEditDistance {
+			group.Reasons = append(group.Reasons, fmt.Sprintf("edit distance %.2f above %.2f", group.EditDistance, report.MaxEditDistance))
+		}
+		if vectors != nil {
+			mean := similarity / float64(pairs)
+			group.Similarity = &mean
+			vectors = vectors[len(out):]
+			if mean < report.MinSimilarity {
+				group.Reasons = append(group.Reasons, fmt.Sprintf("similarity %.2f below %.2f", mean, report.MinSimilarity))
+			}
+		}
+		group.Unstable = len(group.Reasons) > 0
+	}
+	return nil
+}
+
+func (report *StabilityReport) summarize() {
+	index := map[string]int{}
+	sims := map[string]int{}
+	for _, g := range report.Groups {
+		i, ok := index[g.Variant]
+		if !ok {
+			i = len(report.Prompts)
+			index[g.Variant] = i
+			report.Prompts = append(report.Prompts, PromptStability{Variant: g.Variant})
+		}
+		if g.Error != "" {
+			continue
+		}
+		p := &report.Prompts[i]
+		p.Groups++
+		if g.Unstable {
+			p.Unstable++
+		}
+		p.ExactMatchRate += g.ExactMatchRate
+		p.EditDistance += g.EditDistance
+		if g.Similarity != nil {
+			if p.Similarity == nil {
+				p.Similarity = new(float64)
+			}
+			*p.Similarity += *g.Similarity
+			sims[g.Variant]++
+		}
+	}
+	for i := range report.Prompts {
+		p := &report.Prompts[i]
+		if p.Groups > 0 {
+			p.ExactMatchRate /= float64(p.Groups)
+			p.EditDistance /= float64(p.Groups)
+		}
+		if p.Similarity != nil {
+			*p.Similarity /= float64(sims[p.Variant])
+		}
+	}
+}
+
+// Unstable returns the variants with at least one unstable group.
+func (report *StabilityReport) Unstable() []string {
+	var labels []string
+	for _, p := range report.Prompts {
+		if p.Unstable > 0 {
+			labels = append(labels, p.Variant)
+		}
+	}
+	return labels
+}
+
+// Print writes a table of the prompts followed by the unstable groups and
+// the groups that could not be measured.
+func (report *StabilityReport) Print(w io.Writer) {
+	seeds := "seed " + strconv.Itoa(int(report.Seeds[0]))
+	if n := len(report.Seeds); n > 1 && report.Seeds[n-1] != report.Seeds[0] {
+		seeds = fmt.Sprintf("seeds %d-%d", report.Seeds[0], report.Seeds[n-1])
+	}
+	fmt.Fprintf(w, "Stability on %s, %d runs per input with %s\n\n", report.Model, report.Runs, seeds)
+	fmt.Fprintln(w, "| Prompt | Exact match | Edit distance | Similarity | Unstable |")
+	fmt.Fprintln(w, "|---|---|---|---|---|")
+	for _, p := range report.Prompts {
+		similarity := "-"
+		if p.Similarity != nil {
+			similarity = fmt.Sprintf("%.2f", *p.Similarity)
+		}
+		fmt.Fprintf(w, "| %s | %.0f%% | %.2f | %s | %d of %d |\n",
+			p.Variant, p.ExactMatchRate*100, p.EditDistance, similarity, p.Unstable, p.Groups)
+	}
+
+	var unstable, failed []string
+	for _, g := range report.Groups {
+		name := g.Variant + " / " + g.Input
+		if g.Temperature != nil {
+			name += " at temperature " + strconv.FormatFloat(float64(*g.Temperature), 'g', -1, 32)
+		}
+		switch {
+		case g.Error != "":
+			failed = append(failed, fmt.Sprintf("- %s: %s", name, g.Error))
+		case g.Unstable:
+			unstable = append(unstable, fmt.Sprintf("- %s: %s (%d distinct outputs)", name, strings.Join(g.Reasons, "; "), g.Distinct))
+		}
+	}
+	if len(unstable) > 0 {
+		fmt.Fprintf(w, "\nUnstable:\n%s\n", strings.Join(unstable, "\n"))
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(w, "\nNot measured:\n%s\n", strings.Join(failed, "\n"))
+	}
+}
+
+// EditDistance is the word-level Levenshtein distance between a and b
+// divided by the number of words of the longer one: 0 for the same words,
+// 1 for nothing in common.
+func EditDistance(a, b string) float64 {
+	x, y := strings.Fields(a), strings.Fields(b)
+	longest := max(len(x), len(y))
+	if longest == 0 {
+		return 0
+	}
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return float64(prev[len(y)]) / float64(longest)
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// valueOr returns *v, or def if v is nil.
+func valueOr(v *float64, def float64) float64 {
+	if v == nil {
+		return def
+	}
+	return *v
+}
@@ -0,0 +1,149 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/geminitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 0},
+		{"same words here", "same  words\nhere", 0},
+		{"one two three four", "one two three five", 0.25},
+		{"one two", "one two three four", 0.5},
+		{"alpha beta", "gamma delta", 1},
+		{"", "anything", 1},
+	} {
+		assert.InDelta(t, tc.want, EditDistance(tc.a, tc.b), 1e-9, "%q vs %q", tc.a, tc.b)
+		assert.InDelta(t, tc.want, EditDistance(tc.b, tc.a), 1e-9, "symmetric")
+	}
+}
+
+// seedSensitive answers the chatty prompt with a different text per seed,
+// the steady prompt always the same and fails inputs asking for it.
+func seedSensitive(c geminitest.GenerateCall) (*genai.GenerateContentResponse, error) {
+	if strings.Contains(c.Prompt(), "fail") {
+		return nil, assert.AnError
+	}
+	if strings.Contains(c.Config.SystemInstruction.Parts[0].Text, "chatty") {
+		return geminitest.TextResponse(fmt.Sprintf("Reply number %d with new words", *c.Config.Seed)), nil
+	}
+	return geminitest.TextResponse("The same reply every time"), nil
+}
+
+func TestStabilityRunner(t *testing.T) {
+	t.Parallel()
+
+	variants := []Variant{
+		{Label: "steady", File: writeTemp(t, "Be steady.")},
+		{Label: "chatty", File: writeTemp(t, "Be chatty.")},
+	}
+	gen := &geminitest.Generator{Fallback: seedSensitive}
+	runner := &StabilityRunner{
+		Generator:    gen,
+		Embedder:     &gemini.TextEmbedder{Embedder: &geminitest.Embedder{Dimensions: 32}, Model: "models/embed"},
+		Runs:         3,
+		Seed:         5,
+		Temperatures: []float32{0, 1},
+	}
+	report, err := runner.Run(context.Background(), variants, []Input{{Name: "greeting", Text: "Say hi."}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int32{5, 6, 7}, report.Seeds)
+	assert.Equal(t, DefaultMaxEditDistance, report.MaxEditDistance, "unset thresholds get the defaults")
+	assert.Equal(t, DefaultMinSimilarity, report.MinSimilarity)
+	assert.Equal(t, gemini.DefaultModel, report.Model)
+	assert.Len(t, gen.Calls(), 12, "2 prompts × 1 input × 2 temperatures × 3 runs")
+	require.Len(t, report.Groups, 4)
+
+	steady := report.Groups[1]
+	assert.Equal(t, "steady", steady.Variant)
+	assert.Equal(t, float32(1), *steady.Temperature)
+	assert.Equal(t, 1, steady.Distinct)
+	assert.Equal(t, 1.0, steady.ExactMatchRate)
+	assert.Zero(t, steady.EditDistance)
+	assert.InDelta(t, 1, *steady.Similarity, 1e-6)
+	assert.False(t, steady.Unstable)
+
+	chatty := report.Groups[2]
+	assert.Equal(t, "chatty", chatty.Variant)
+	assert.Equal(t, []Sample{{5, "Reply number 5 with new words", ""}, {6, "Reply number 6 with new words", ""}, {7, "Reply number 7 with new words", ""}}, chatty.Samples)
+	assert.Equal(t, 3, chatty.Distinct)
+	assert.Zero(t, chatty.ExactMatchRate)
+	assert.InDelta(t, 1.0/6, chatty.EditDistance, 1e-9, "one word in six differs")
+	assert.True(t, chatty.Unstable)
+	assert.Contains(t, chatty.Reasons[0], "similarity")
+
+	for _, call := range gen.Calls() {
+		if strings.Contains(call.Config.SystemInstruction.Parts[0].Text, "chatty") && *call.Config.Seed == 6 {
+			assert.Contains(t, []float32{0, 1}, *call.Config.Temperature)
+		}
+	}
+
+	assert.Equal(t, []string{"chatty"}, report.Unstable())
+	assert.Equal(t, PromptStability{Variant: "steady", Groups: 2, ExactMatchRate: 1, Similarity: report.Prompts[0].Similarity}, report.Prompts[0])
+
+	var out strings.Builder
+	report.Print(&out)
+	assert.Contains(t, out.String(), "Stability on models/gemini-2.0-flash, 3 runs per input with seeds 5-7")
+	assert.Contains(t, out.String(), "| steady | 100% | 0.00 | 1.00 | 0 of 2 |")
+	assert.Contains(t, out.String(), "- chatty / greeting at temperature 0: similarity")
+	assert.Contains(t, out.String(), "(3 distinct outputs)")
+}
+
+func TestStabilityRunnerFixedSeed(t *testing.T) {
+	t.Parallel()
+
+	gen := &geminitest.Generator{Fallback: seedSensitive}
+	runner := &StabilityRunner{Generator: gen, Runs: 4, Seed: 5, FixedSeed: true}
+	report, err := runner.Run(context.Background(), []Variant{{Label: "chatty", File: writeTemp(t, "Be chatty.")}},
+		[]Input{{Name: "hi", Text: "Say hi."}, {Name: "broken", Text: "Please fail."}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int32{5, 5, 5, 5}, report.Seeds)
+	assert.InDelta(t, 0.3, *report.Groups[0].Temperature, 1e-6, "the profile's temperature")
+	assert.Equal(t, 1.0, report.Groups[0].ExactMatchRate)
+	assert.Nil(t, report.Groups[0].Similarity, "no embedder")
+	assert.Equal(t, "needs at least two outputs, got 0", report.Groups[1].Error)
+	assert.Empty(t, report.Unstable())
+	assert.Equal(t, 1, report.Prompts[0].Groups, "groups without outputs are not averaged")
+
+	var out strings.Builder
+	report.Print(&out)
+	assert.Contains(t, out.String(), "with seed 5\n")
+	assert.Contains(t, out.String(), "| chatty | 100% | 0.00 | - | 0 of 1 |")
+	assert.Contains(t, out.String(), "Not measured:\n- chatty / broken at temperature 0.3: needs at least two outputs")
+}
+
+func TestStabilityRunnerZeroThresholds(t *testing.T) {
+	t.Parallel()
+
+	runner := &StabilityRunner{
+		Generator:       &geminitest.Generator{Fallback: seedSensitive},
+		Embedder:        &gemini.TextEmbedder{Embedder: &geminitest.Embedder{Dimensions: 32}, Model: "models/embed"},
+		Runs:            2,
+		Seed:            5,
+		MaxEditDistance: gemini.F64(0),
+		MinSimilarity:   gemini.F64(0),
+	}
+	report, err := runner.Run(context.Background(), []Variant{{Label: "chatty", File: writeTemp(t, "Be chatty.")}},
+		[]Input{{Name: "hi", Text: "Say hi."}})
+	require.NoError(t, err)
+
+	assert.Zero(t, report.MaxEditDistance, "an explicit zero is kept")
+	assert.Zero(t, report.MinSimilarity)
+	assert.Equal(t, []string{"edit distance 0.17 above 0.00"}, report.Groups[0].Reasons, "any change is too much, no similarity is too low")
+}
@@ -0,0 +1,126 @@
+//revive:disable:package-comments,exported
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a YAML test suite for a prompt: the prompt is the system
+// instruction and every case sends one input to the model and checks the
+// output with assertions.
+//
+//	prompt: task-specific/expert-MQL5-MetaTrader5-assistant
+//	version: v6
+//	cases:
+//	  - name: include-files
+//	    input: How should I share code between two indicators?
+//	    assert:
+//	      - contains: "#include"
+//	      - regex: '\.mqh\b'
+//	      - max_length: 6000
+type Suite struct {
+	// Prompt is the ID of the prompt in the library; PromptFile names a
+	// file relative to the prompts directory instead.
+	Prompt     string `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+	PromptFile string `yaml:"prompt_file,omitempty" json:"prompt_file,omitempty"`
+	// Version selects a version of Prompt; the latest is used if empty.
+	Version string       `yaml:"version,omitempty" json:"version,omitempty"`
+	Model   string       `yaml:"model,omitempty" json:"model,omitempty"`
+	Profile string       `yaml:"profile,omitempty" json:"profile,omitempty"`
+	Vars    prompts.Vars `yaml:"vars,omitempty" json:"vars,omitempty"`
+	Cases   []Case       `yaml:"cases" json:"cases"`
+	// Rubric names the rubric the outputs are judged by, in the rubrics
+	// directory of the prompts, or a rubric file.
+	Rubric string `yaml:"rubric,omitempty" json:"rubric,omitempty"`
+
+	// Path is the file the suite was loaded from.
+	Path string `yaml:"-" json:"path,omitempty"`
+}
+
+// Case is one input of a suite. Its variables are merged over the suite's.
+type Case struct {
+	Name       string       `yaml:"name" json:"name"`
+	Input      string       `yaml:"input" json:"input"`
+	Vars       prompts.Vars `yaml:"vars,omitempty" json:"vars,omitempty"`
+	Assertions []Assertion  `yaml:"assert,omitempty" json:"assert,omitempty"`
+}
+
+// caseName keeps case names usable as golden file names.
+var caseName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// LoadSuite reads and validates a suite file.
+func LoadSuite(filename string) (*Suite, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var s Suite
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse suite %q: %w", filename, err)
+	}
+	s.Path = filename
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("suite %q: %w", filename, err)
+	}
+	return &s, nil
+}
+
+func (s *Suite) validate() error {
+	if (s.Prompt == "") == (s.PromptFile == "") {
+		return fmt.Errorf("needs exactly one of prompt and prompt_file")
+	}
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("has no cases")
+	}
+	seen := map[string]bool{}
+	for i, c := range s.Cases {
+		if !caseName.MatchString(c.Name) {
+			return fmt.Errorf("case %d: name %q must be letters, digits, '.', '_' or '-'", i+1, c.Name)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("case %q is defined twice", c.Name)
+		}
+		seen[c.Name] = true
+		if strings.TrimSpace(c.Input) == "" {
+			return fmt.Errorf("case %q has no input", c.Name)
+		}
+		for j, a := range c.Assertions {
+			if err := a.validate(); err != nil {
+				return fmt.Errorf("case %q, assertion %d: %w", c.Name, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GoldenDir is where the golden outputs of the suite are stored:
+// mql5.yaml keeps them in mql5.golden/<case>.md.
+func (s *Suite) GoldenDir() string {
+	return strings.TrimSuffix(s.Path, filepath.Ext(s.Path)) + ".golden"
+}
+
+// GoldenFile names the golden output of a case.
+func (s *Suite) GoldenFile(c Case) string {
+	return filepath.Join(s.GoldenDir(), c.Name+".md")
+}
+
+// vars merges the suite variables with those of the case.
+func (s *Suite) vars(c Case) prompts.Vars {
+	vars := prompts.Vars{}
+	for name, value := range s.Vars {
+		vars[name] = value
+	}
+	for name, value := range c.Vars {
+		vars[name] = value
+	}
+	return vars
+}
@@ -0,0 +1,244 @@
+package eval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/geminitest"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, filename, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+}
+
+// project creates a prompts directory with two versions of an assistant
+// prompt and a suite for it.
+func project(t *testing.T, suite string) (promptsDir, suiteFile string) {
+	t.Helper()
+	root := t.TempDir()
+	promptsDir = filepath.Join(root, "prompts")
+	writeFile(t, filepath.Join(promptsDir, "task-specific", "assistant", "v1.md"), "You are terse.")
+	writeFile(t, filepath.Join(promptsDir, "task-specific", "assistant", "v2.md"), "You are a {{ .tone }} assistant.")
+	suiteFile = filepath.Join(root, "evals", "assistant.yaml")
+	writeFile(t, suiteFile, suite)
+	return promptsDir, suiteFile
+}
+
+const assistantSuite = `prompt: task-specific/assistant
+vars: {tone: friendly}
+cases:
+  - name: greeting
+    input: Say hello.
+    assert:
+      - contains: hello
+        ignore_case: true
+      - max_length: 20
+  - name: order
+    input: Give me an order as JSON.
+    assert:
+      - json_schema: order.schema.yaml
+`
+
+func TestLoadSuite(t *testing.T) {
+	t.Parallel()
+
+	_, file := project(t, assistantSuite)
+	suite, err := LoadSuite(file)
+	require.NoError(t, err)
+	assert.Equal(t, "task-specific/assistant", suite.Prompt)
+	require.Len(t, suite.Cases, 2)
+	assert.Equal(t, "greeting", suite.Cases[0].Name)
+	assert.Equal(t, 20, *suite.Cases[0].Assertions[1].MaxLength)
+	assert.Equal(t, filepath.Join(filepath.Dir(file), "assistant.golden", "order.md"), suite.GoldenFile(suite.Cases[1]))
+}
+
+func TestLoadRepositorySuites(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join("..", "..", "..")
+	library, err := prompts.LoadLibrary(filepath.Join(root, "prompts"))
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(root, "evals", "*.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, files, filepath.Join(root, "evals", "expert-MQL5-MetaTrader5-assistant.yaml"))
+	for _, file := range files {
+		suite, err := LoadSuite(file)
+		require.NoError(t, err)
+		if suite.Prompt != "" {
+			_, err = library.Get(suite.Prompt, suite.Version)
+			assert.NoError(t, err, file)
+		}
+		assert.DirExists(t, suite.GoldenDir())
+	}
+}
+
+func TestLoadSuiteErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name, suite, err string
+	}{
+		{"no prompt", "cases: [{name: a, input: x}]", "needs exactly one of prompt and prompt_file"},
+		{"no cases", "prompt: p", "has no cases"},
+		{"bad name", "prompt: p\ncases: [{name: a/b, input: x}]", `case 1: name "a/b" must be`},
+		{"duplicate", "prompt: p\ncases: [{name: a, input: x}, {name: a, input: y}]", `case "a" is defined twice`},
+		{"no input", "prompt: p\ncases: [{name: a}]", `case "a" has no input`},
+		{"two checks", "prompt: p\ncases: [{name: a, input: x, assert: [{contains: a, regex: b}]}]", "assertion 1: needs exactly one of"},
+		{"bad regex", "prompt: p\ncases: [{name: a, input: x, assert: [{regex: '('}]}]", "assertion 1: invalid regex"},
+		{"unknown field", "prompt: p\ncases: [{name: a, input: x, expect: y}]", "field expect not found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			file := filepath.Join(t.TempDir(), "suite.yaml")
+			writeFile(t, file, tc.suite)
+			_, err := LoadSuite(file)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	t.Parallel()
+
+	ten := 10
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"symbol", "lots"},
+		"properties": map[string]any{
+			"symbol": map[string]any{"type": "string", "pattern": "^[A-Z]{6}$"},
+			"lots":   map[string]any{"type": "number", "minimum": 0.01},
+			"side":   map[string]any{"enum": []any{"buy", "sell"}},
+		},
+		"additionalProperties": false,
+	}
+	for _, tc := range []struct {
+		name   string
+		a      Assertion
+		output string
+		want   string
+	}{
+		{"contains", Assertion{Contains: "OrderSend"}, "use OrderSend()", ""},
+		{"contains fails", Assertion{Contains: "OrderSend"}, "use ordersend()", `expected output to contain "OrderSend"`},
+		{"contains ignore case", Assertion{Contains: "OrderSend", IgnoreCase: true}, "use ordersend()", ""},
+		{"not contains", Assertion{NotContains: "Heiken"}, "Heiken Ashi", `expected output not to contain "Heiken"`},
+		{"regex", Assertion{Regex: `\.mqh\b`}, "#include <Tools.mqh>", ""},
+		{"regex fails", Assertion{Regex: `\.mqh\b`}, "no includes", `expected output to match /\.mqh\b/`},
+		{"not regex", Assertion{NotRegex: `(?i)as an ai`}, "As an AI model", `expected output not to match /(?i)as an ai/, found "As an AI"`},
+		{"min length", Assertion{MinLength: &ten}, "short", "expected at least 10 characters, got 5"},
+		{"max length counts characters", Assertion{MaxLength: &ten}, "ŐŐŐŐŐŐŐŐŐŐ", ""},
+		{"schema", Assertion{JSONSchema: schema}, "```json\n{\"symbol\": \"EURUSD\", \"lots\": 0.1, \"side\": \"buy\"}\n```", ""},
+		{"schema not json", Assertion{JSONSchema: schema}, "EURUSD", "expected JSON output"},
+		{
+			"schema violations", Assertion{JSONSchema: schema}, `{"symbol": "eurusd", "side": "hold", "extra": 1}`,
+			`output does not match the JSON schema: $: missing required property "lots"; $: unexpected property "extra"; ` +
+				`$.side: hold is not one of [buy sell]; $.symbol: "eurusd" does not match /^[A-Z]{6}$/`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := tc.a.Check(tc.output, "")
+			if tc.want == "" {
+				assert.Empty(t, got)
+			} else {
+				assert.True(t, strings.HasPrefix(got, tc.want), got)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaTypes(t *testing.T) {
+	t.Parallel()
+
+	schema := map[string]any{
+		"type":     "array",
+		"minItems": 1,
+		"items":    map[string]any{"type": []any{"integer", "null"}, "maximum": 5},
+	}
+	assert.Empty(t, validateSchema(schema, []any{float64(1), nil}, "$"))
+	assert.Equal(t, []string{"$[0]: expected [integer null], got number", "$[1]: 9 is greater than the maximum 5"},
+		validateSchema(schema, []any{1.5, float64(9)}, "$"))
+	assert.Equal(t, []string{"$: expected at least 1 items, got 0"}, validateSchema(schema, []any{}, "$"))
+	assert.Equal(t, []string{"$: expected array, got object"}, validateSchema(schema, map[string]any{}, "$"))
+}
+
+func TestRunnerGolden(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	promptsDir, file := project(t, assistantSuite)
+	writeFile(t, filepath.Join(filepath.Dir(file), "order.schema.yaml"), "type: object\nrequired: [id]\n")
+	suite, err := LoadSuite(file)
+	require.NoError(t, err)
+
+	gen := geminitest.NewGenerator("Hello there!", `{"id": 7}`)
+	runner := &Runner{Generator: gen, Golden: GoldenUpdate}
+	runner.PromptsDir = promptsDir
+	report, err := runner.Run(ctx, suite, "")
+	require.NoError(t, err)
+	assert.Zero(t, report.Failed())
+	assert.Equal(t, "v2", report.Version, "the latest version by default")
+	assert.Equal(t, GoldenUpdated, report.Cases[0].Golden)
+	assert.Equal(t, "You are a friendly assistant.", gen.Calls()[0].Config.SystemInstruction.Parts[0].Text)
+	gen.AssertPrompts(t, "Say hello.", "Give me an order as JSON.")
+	golden, err := os.ReadFile(suite.GoldenFile(suite.Cases[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there!", string(golden))
+
+	// A changed output is reported with a diff and fails only in strict mode.
+	runner.Generator = geminitest.NewGenerator("Hello, friend!", `{"id": 7}`)
+	runner.Golden = GoldenReport
+	report, err = runner.Run(ctx, suite, "")
+	require.NoError(t, err)
+	assert.Zero(t, report.Failed())
+	assert.Equal(t, GoldenChanged, report.Cases[0].Golden)
+	assert.Contains(t, report.Cases[0].Diff, "-Hello there!\n\\ No newline at end of file\n+Hello, friend!")
+	assert.Equal(t, GoldenMatch, report.Cases[1].Golden)
+
+	runner.Generator = geminitest.NewGenerator("Hello, friend!", `{"id": 7}`)
+	runner.Golden = GoldenStrict
+	report, err = runner.Run(ctx, suite, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed())
+	assert.Contains(t, report.Cases[0].Failures[0], "output differs from")
+
+	var out strings.Builder
+	report.Print(&out)
+	assert.Contains(t, out.String(), "FAIL greeting")
+	assert.Contains(t, out.String(), "[golden changed]")
+	assert.Contains(t, out.String(), "1 passed, 1 failed")
+}
+
+func TestRunnerVersionAndFailures(t *testing.T) {
+	t.Parallel()
+
+	promptsDir, file := project(t, assistantSuite)
+	suite, err := LoadSuite(file)
+	require.NoError(t, err)
+
+	gen := geminitest.NewGenerator("Goodbye, this answer is far too long.").Fail(assert.AnError)
+	runner := &Runner{Generator: gen}
+	runner.PromptsDir = promptsDir
+	runner.Model = "models/other"
+	report, err := runner.Run(context.Background(), suite, "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", report.Version)
+	assert.Equal(t, "models/other", report.Model)
+	assert.Equal(t, "You are terse.", gen.Calls()[0].Config.SystemInstruction.Parts[0].Text)
+
+	require.Len(t, report.Cases, 2)
+	assert.Equal(t, []string{`expected output to contain "hello"`, "expected at most 20 characters, got 37"}, report.Cases[0].Failures)
+	assert.Contains(t, report.Cases[1].Error, assert.AnError.Error())
+	assert.Empty(t, report.Cases[1].Golden, "failed calls are not compared")
+	assert.Equal(t, 2, report.Failed())
+
+	_, err = runner.Run(context.Background(), suite, "v9")
+	assert.EqualError(t, err, `prompt "task-specific/assistant" has no version "v9"`)
+}
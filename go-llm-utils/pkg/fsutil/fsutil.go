@@ -0,0 +1,47 @@
+//revive:disable:package-comments,exported
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteTemp writes data to a new hidden file in the directory of filename
+// and returns its name, so the caller can move it into place in one step.
+func WriteTemp(filename string, data []byte, perm os.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	name := f.Name()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+// WriteFile replaces filename with data atomically: readers see either the
+// previous contents or the new ones, and a crash never leaves a partial file.
+func WriteFile(filename string, data []byte, perm os.FileMode) error {
+	tmp, err := WriteTemp(filename, data, perm)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
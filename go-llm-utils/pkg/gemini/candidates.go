@@ -0,0 +1,169 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// CandidateScorer rates a candidate; higher scores are better.
+type CandidateScorer func(*genai.Candidate) float64
+
+// Selection strategies for SelectCandidate. SelectFirst keeps the behavior
+// of WriteGeminiTextToMarkdown.
+var (
+	SelectFirst CandidateScorer = func(*genai.Candidate) float64 { return 0 }
+
+	SelectLongest CandidateScorer = func(c *genai.Candidate) float64 {
+		return float64(len([]rune(candidateText(c))))
+	}
+
+	// SelectHighestLogprob prefers the candidate the model was most confident
+	// about. Candidates without log probabilities score lowest.
+	SelectHighestLogprob CandidateScorer = func(c *genai.Candidate) float64 {
+		if c.AvgLogprobs == 0 {
+			return math.Inf(-1)
+		}
+		return c.AvgLogprobs
+	}
+)
+
+// selectionStrategies names the built-in strategies for command line flags.
+var selectionStrategies = map[string]CandidateScorer{
+	"first":   SelectFirst,
+	"longest": SelectLongest,
+	"logprob": SelectHighestLogprob,
+}
+
+// SelectionStrategy returns the built-in strategy with the given name.
+func SelectionStrategy(name string) (CandidateScorer, error) {
+	scorer, ok := selectionStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown selection strategy %q (available: %s)", name, strings.Join(SelectionStrategies(), ", "))
+	}
+	return scorer, nil
+}
+
+// SelectionStrategies lists the names accepted by SelectionStrategy.
+func SelectionStrategies() []string {
+	names := make([]string, 0, len(selectionStrategies))
+	for name := range selectionStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectCandidate returns the text of the best scoring candidate that has
+// any. Ties go to the earlier candidate. If no candidate has text the error
+// is the one CheckResponse gives for the response.
+func SelectCandidate(resp *genai.GenerateContentResponse, score CandidateScorer) (int, string, error) {
+	if score == nil {
+		score = SelectFirst
+	}
+
+	best, bestScore := -1, math.Inf(-1)
+	if resp != nil {
+		for i, cand := range resp.Candidates {
+			if cand == nil || blockedFinishReasons[cand.FinishReason] || candidateText(cand) == "" {
+				continue
+			}
+			if s := score(cand); best < 0 || s > bestScore {
+				best, bestScore = i, s
+			}
+		}
+	}
+	if best < 0 {
+		if err := CheckResponse(resp); err != nil {
+			return -1, "", err
+		}
+		return -1, "", fmt.Errorf("%w: no candidate has text", ErrEmptyResponse)
+	}
+	return best, candidateText(resp.Candidates[best]), nil
+}
+
+// WriteSelectedCandidate writes the text of the candidate chosen by score to
+// a markdown file.
+func WriteSelectedCandidate(resp *genai.GenerateContentResponse, score CandidateScorer, outputPath string) error {
+	_, text, err := SelectCandidate(resp, score)
+	if err != nil {
+		return err
+	}
+	return WriteTextToMarkdown(text, outputPath)
+}
+
+// CandidateFile names the file of candidate i (counting from 0):
+// response.md becomes response-1.md, response-2.md and so on.
+func CandidateFile(outputPath string, i int) string {
+	ext := filepath.Ext(outputPath)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(outputPath, ext), i+1, ext)
+}
+
+// WriteCandidatesToFiles writes every candidate with text to its own
+// numbered file, see CandidateFile, and returns the files written.
+// Candidates without text are skipped, so the numbers keep matching the
+// candidate positions. opts applies to each file as in WriteFile.
+func WriteCandidatesToFiles(resp *genai.GenerateContentResponse, outputPath string, opts FileOptions) ([]string, error) {
+	if err := checkCandidates(resp); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for i, cand := range resp.Candidates {
+		text := candidateText(cand)
+		if text == "" {
+			continue
+		}
+		filename, err := writeMarkdown(text, CandidateFile(outputPath, i), opts)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, filename)
+	}
+	return written, nil
+}
+
+// WriteCandidatesToMarkdown writes all candidates to one markdown document
+// with a section per candidate and returns the file written, see WriteFile.
+// Candidates without text get a note saying why.
+func WriteCandidatesToMarkdown(resp *genai.GenerateContentResponse, outputPath string, opts FileOptions) (string, error) {
+	if err := checkCandidates(resp); err != nil {
+		return "", err
+	}
+	return writeMarkdown(CandidatesMarkdown(resp), outputPath, opts)
+}
+
+// CandidatesMarkdown renders all candidates of resp as markdown sections.
+func CandidatesMarkdown(resp *genai.GenerateContentResponse) string {
+	var b strings.Builder
+	for i, cand := range resp.Candidates {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## Candidate %d\n\n", i+1)
+
+		text := candidateText(cand)
+		if text == "" {
+			reason := "no text"
+			if cand != nil && cand.FinishReason != "" {
+				reason += fmt.Sprintf(", finish reason %s", cand.FinishReason)
+			}
+			fmt.Fprintf(&b, "_%s_\n", reason)
+			continue
+		}
+		b.WriteString(strings.TrimRight(text, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// checkCandidates fails unless at least one candidate has text.
+func checkCandidates(resp *genai.GenerateContentResponse) error {
+	_, _, err := SelectCandidate(resp, SelectFirst)
+	return err
+}
@@ -0,0 +1,145 @@
+package gemini
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func candidatesResponse() *genai.GenerateContentResponse {
+	candidate := func(text string, reason genai.FinishReason, logprobs float64) *genai.Candidate {
+		c := &genai.Candidate{FinishReason: reason, AvgLogprobs: logprobs}
+		if text != "" {
+			c.Content = genai.NewContentFromText(text, genai.RoleModel)
+		}
+		return c
+	}
+	return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{
+		candidate("short", genai.FinishReasonStop, -0.9),
+		candidate("", genai.FinishReasonSafety, 0),
+		candidate("the longest answer", genai.FinishReasonStop, -0.4),
+		candidate("confident", genai.FinishReasonStop, -0.1),
+	}}
+}
+
+func TestSelectCandidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		strategy string
+		index    int
+		text     string
+	}{
+		{"first", 0, "short"},
+		{"longest", 2, "the longest answer"},
+		{"logprob", 3, "confident"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			t.Parallel()
+			score, err := SelectionStrategy(tt.strategy)
+			require.NoError(t, err)
+			index, text, err := SelectCandidate(candidatesResponse(), score)
+			require.NoError(t, err)
+			assert.Equal(t, tt.index, index)
+			assert.Equal(t, tt.text, text)
+		})
+	}
+
+	t.Run("custom scorer", func(t *testing.T) {
+		t.Parallel()
+		shortest := func(c *genai.Candidate) float64 { return -float64(len(candidateText(c))) }
+		index, text, err := SelectCandidate(candidatesResponse(), shortest)
+		require.NoError(t, err)
+		assert.Equal(t, 0, index)
+		assert.Equal(t, "short", text)
+	})
+
+	t.Run("skips blocked first candidate", func(t *testing.T) {
+		t.Parallel()
+		resp := candidatesResponse()
+		resp.Candidates = resp.Candidates[1:]
+		index, text, err := SelectCandidate(resp, SelectFirst)
+		require.NoError(t, err)
+		assert.Equal(t, 1, index)
+		assert.Equal(t, "the longest answer", text)
+	})
+
+	t.Run("no text anywhere", func(t *testing.T) {
+		t.Parallel()
+		resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonSafety}}}
+		_, _, err := SelectCandidate(resp, SelectLongest)
+		assert.ErrorIs(t, err, ErrBlocked)
+	})
+
+	_, err := SelectionStrategy("best")
+	assert.EqualError(t, err, `unknown selection strategy "best" (available: first, logprob, longest)`)
+}
+
+func TestCandidatesMarkdownNilCandidate(t *testing.T) {
+	t.Parallel()
+
+	resp := candidatesResponse()
+	resp.Candidates = []*genai.Candidate{nil, resp.Candidates[0]}
+	assert.Equal(t, "## Candidate 1\n\n_no text_\n\n## Candidate 2\n\nshort\n", CandidatesMarkdown(resp))
+
+	index, _, err := SelectCandidate(resp, SelectFirst)
+	require.NoError(t, err)
+	assert.Equal(t, 1, index)
+}
+
+func TestWriteCandidates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "response.md")
+
+	written, err := WriteCandidatesToFiles(candidatesResponse(), output, FileOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "response-1.md"),
+		filepath.Join(dir, "response-3.md"),
+		filepath.Join(dir, "response-4.md"),
+	}, written)
+	data, err := os.ReadFile(filepath.Join(dir, "response-3.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "the longest answer", string(data))
+
+	_, err = WriteCandidatesToFiles(candidatesResponse(), output, FileOptions{IfExists: Fail})
+	require.ErrorIs(t, err, fs.ErrExist, "existing candidate files are kept")
+
+	file, err := WriteCandidatesToMarkdown(candidatesResponse(), output, FileOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, output, file)
+	data, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, `## Candidate 1
+
+short
+
+## Candidate 2
+
+_no text, finish reason SAFETY_
+
+## Candidate 3
+
+the longest answer
+
+## Candidate 4
+
+confident
+`, string(data))
+
+	_, err = WriteCandidatesToMarkdown(candidatesResponse(), output, FileOptions{IfExists: Fail})
+	require.ErrorIs(t, err, fs.ErrExist)
+
+	require.NoError(t, WriteSelectedCandidate(candidatesResponse(), SelectLongest, output))
+	data, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "the longest answer", string(data))
+}
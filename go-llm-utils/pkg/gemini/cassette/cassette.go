@@ -0,0 +1,363 @@
+//revive:disable:package-comments,exported
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/fsutil"
+)
+
+// Environment variables read by FromEnv.
+const (
+	// PathEnv names the cassette file; no cassette is used when it is empty.
+	PathEnv = "GEMINI_CASSETTE"
+	// ModeEnv selects "replay" (the default) or "record".
+	ModeEnv = "GEMINI_CASSETTE_MODE"
+)
+
+// Redacted replaces secrets in recorded interactions.
+const Redacted = "REDACTED"
+
+// ReplayAPIKey can stand in for the API key in Replay mode, where the key
+// is never sent anywhere.
+const ReplayAPIKey = "cassette-replay-key"
+
+// Mode says whether a Recorder talks to the real API.
+type Mode int
+
+const (
+	// Replay answers requests from the cassette and never touches the network.
+	Replay Mode = iota
+	// Record forwards requests to the real API and saves every exchange,
+	// replacing the previous contents of the cassette.
+	Record
+)
+
+// ParseMode returns the mode with the given name; the empty string means Replay.
+func ParseMode(name string) (Mode, error) {
+	switch name {
+	case "", "replay":
+		return Replay, nil
+	case "record":
+		return Record, nil
+	}
+	return 0, fmt.Errorf("unknown cassette mode %q (available: replay, record)", name)
+}
+
+// secretHeaders are dropped from recordings; their values are also redacted
+// wherever else they appear.
+var secretHeaders = []string{"X-Goog-Api-Key", "Authorization", "Cookie", "Set-Cookie"}
+
+// volatileHeaders change on every run and are not recorded.
+var volatileHeaders = []string{"Date", "Server-Timing", "User-Agent", "X-Goog-Api-Client"}
+
+// secretParams are query parameters redacted in recorded URLs.
+var secretParams = []string{"key", "access_token"}
+
+// Cassette is the file format: the recorded exchanges in the order they happened.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded part of an HTTP request.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response is the recorded part of an HTTP response.
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Load reads a cassette file.
+func Load(filename string) (*Cassette, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %q: %w", filename, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette as indented JSON, creating its directory.
+func (c *Cassette) Save(filename string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := fsutil.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write cassette %q: %w", filename, err)
+	}
+	return nil
+}
+
+// Recorder is an http.RoundTripper that records exchanges with the API to
+// a cassette or replays them from it. Requests are matched on method, URL
+// and body; identical requests are answered in recording order.
+type Recorder struct {
+	path string
+	mode Mode
+	// Transport sends requests in Record mode; it defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New creates a recorder for the cassette file. In Replay mode the file must exist.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, cassette: &Cassette{}}
+	if mode == Replay {
+		c, err := Load(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("cassette %q not found; record it with %s=record", path, ModeEnv)
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	}
+	return r, nil
+}
+
+// FromEnv creates the recorder configured by PathEnv and ModeEnv, or
+// returns nil if PathEnv is not set.
+func FromEnv() (*Recorder, error) {
+	path := os.Getenv(PathEnv)
+	if path == "" {
+		return nil, nil
+	}
+	mode, err := ParseMode(os.Getenv(ModeEnv))
+	if err != nil {
+		return nil, err
+	}
+	return New(path, mode)
+}
+
+// Mode returns the mode of the recorder.
+func (r *Recorder) Mode() Mode { return r.mode }
+
+// Client returns an HTTP client that sends its requests through the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Unused returns the recorded interactions that were not replayed, which
+// usually means the code under test stopped making a request it used to.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.cassette.Interactions[i])
+		}
+	}
+	return unused
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read request body: %w", err)
+	}
+
+	if r.mode == Record {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	recorded := scrubRequest(req, body, secretsOf(req))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || !matches(in.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+		return in.Response.httpResponse(req), nil
+	}
+	return nil, fmt.Errorf("cassette %s: no recorded interaction for %s %s", r.path, recorded.Method, recorded.URL)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	secrets := secretsOf(req)
+	in := Interaction{
+		Request: scrubRequest(req, body, secrets),
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: scrubHeaders(resp.Header, secrets),
+			Body:    redact(string(respBody), secrets),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.used = append(r.used, true)
+	// Saving after every exchange keeps what was recorded if the test dies.
+	if err := r.cassette.Save(r.path); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// secretsOf collects the credentials a request carries.
+func secretsOf(req *http.Request) []string {
+	var secrets []string
+	for _, name := range secretHeaders {
+		for _, v := range req.Header.Values(name) {
+			v = strings.TrimSpace(strings.TrimPrefix(v, "Bearer "))
+			if v != "" {
+				secrets = append(secrets, v)
+			}
+		}
+	}
+	query := req.URL.Query()
+	for _, name := range secretParams {
+		if v := query.Get(name); v != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
+}
+
+func scrubRequest(req *http.Request, body []byte, secrets []string) Request {
+	u := *req.URL
+	query := u.Query()
+	for _, name := range secretParams {
+		if query.Has(name) {
+			query.Set(name, Redacted)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return Request{
+		Method:  req.Method,
+		URL:     redact(u.String(), secrets),
+		Headers: scrubHeaders(req.Header, secrets),
+		Body:    redact(string(body), secrets),
+	}
+}
+
+// scrubHeaders drops credentials and headers that change on every run.
+func scrubHeaders(h http.Header, secrets []string) http.Header {
+	out := http.Header{}
+	for name, values := range h {
+		name = http.CanonicalHeaderKey(name)
+		if slices.Contains(secretHeaders, name) || slices.Contains(volatileHeaders, name) {
+			continue
+		}
+		for _, v := range values {
+			out.Add(name, redact(v, secrets))
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+func matches(recorded, req Request) bool {
+	if recorded.Method != req.Method || recorded.Body != req.Body {
+		return false
+	}
+	a, errA := url.Parse(recorded.URL)
+	b, errB := url.Parse(req.URL)
+	if errA != nil || errB != nil {
+		return recorded.URL == req.URL
+	}
+	// The host differs when the cassette was recorded against another
+	// server, for example a local fake, and base URLs with and without a
+	// trailing slash give different paths.
+	return path.Clean(a.Path) == path.Clean(b.Path) && a.Query().Encode() == b.Query().Encode()
+}
+
+func (r Response) httpResponse(req *http.Request) *http.Response {
+	header := r.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,130 @@
+package cassette
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+const secretKey = "AIza-secret-key-123"
+
+// upstream stands in for the real API while recording. It echoes the prompt
+// and counts the requests it served.
+func upstream(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, secretKey, r.Header.Get("x-goog-api-key"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Date", "Tue, 01 Jul 2025 12:00:00 GMT")
+		answer := "hello"
+		if strings.Contains(string(body), "second") {
+			answer = "again"
+		}
+		_, _ = io.WriteString(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "`+answer+`"}]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 2, "candidatesTokenCount": 1, "totalTokenCount": 3},
+			"modelVersion": "gemini-2.0-flash", "echoedKey": "`+secretKey+`"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func generate(t *testing.T, client *genai.Client, prompt string) string {
+	t.Helper()
+	resp, err := client.Models.GenerateContent(context.Background(), "models/gemini-2.0-flash", genai.Text(prompt), nil)
+	require.NoError(t, err)
+	text, err := gemini.ResponseText(resp)
+	require.NoError(t, err)
+	return text
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := upstream(t, &calls)
+	path := filepath.Join(t.TempDir(), "cassettes", "generate.json")
+
+	recorder, err := New(path, Record)
+	require.NoError(t, err)
+	client, err := gemini.NewGenAIClientWithOptions(context.Background(), gemini.ClientOptions{
+		APIKey: secretKey, HTTPClient: recorder.Client(), BaseURL: srv.URL,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", generate(t, client, "first"))
+	assert.Equal(t, "again", generate(t, client, "second"))
+	assert.Equal(t, int32(2), calls.Load())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), secretKey, "API key scrubbed")
+	c, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 2)
+	assert.Contains(t, c.Interactions[0].Response.Body, `"echoedKey": "REDACTED"`)
+	assert.NotContains(t, string(data), "Tue, 01 Jul 2025", "volatile headers dropped")
+
+	// Replay needs neither the server nor the real key, and the recorded
+	// host does not have to match.
+	srv.Close()
+	replayer, err := New(path, Replay)
+	require.NoError(t, err)
+	client, err = gemini.NewGenAIClientWithOptions(context.Background(), gemini.ClientOptions{
+		APIKey: ReplayAPIKey, HTTPClient: replayer.Client(), BaseURL: "https://generativelanguage.googleapis.com/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "again", generate(t, client, "second"))
+	assert.Len(t, replayer.Unused(), 1)
+	assert.Equal(t, "hello", generate(t, client, "first"))
+	assert.Empty(t, replayer.Unused())
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Every recorded interaction is replayed once.
+	_, err = client.Models.GenerateContent(context.Background(), "models/gemini-2.0-flash", genai.Text("first"), nil)
+	assert.ErrorContains(t, err, "no recorded interaction for POST")
+}
+
+func TestNewReplayMissingCassette(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"), Replay)
+	assert.ErrorContains(t, err, "not found; record it with GEMINI_CASSETTE_MODE=record")
+}
+
+func TestScrubRequest(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/v1beta/models?key=k123&pageSize=5", nil)
+	req.Header.Set("Authorization", "Bearer tok456")
+	req.Header.Set("Accept", "application/json")
+
+	recorded := scrubRequest(req, []byte(`{"token": "tok456"}`), secretsOf(req))
+	assert.Equal(t, "https://example.com/v1beta/models?key=REDACTED&pageSize=5", recorded.URL)
+	assert.Equal(t, http.Header{"Accept": {"application/json"}}, recorded.Headers)
+	assert.Equal(t, `{"token": "REDACTED"}`, recorded.Body)
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseMode("")
+	require.NoError(t, err)
+	assert.Equal(t, Replay, mode)
+	mode, err = ParseMode("record")
+	require.NoError(t, err)
+	assert.Equal(t, Record, mode)
+	_, err = ParseMode("live")
+	assert.EqualError(t, err, `unknown cassette mode "live" (available: replay, record)`)
+}
@@ -4,6 +4,7 @@ package gemini
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 
 	"google.golang.org/genai"
@@ -18,16 +19,46 @@ func NewGenAIClient(ctx context.Context) (*genai.Client, error) {
 	return newGenAIClientWithAPIKey(ctx, apiKey)
 }
 
+// ClientOptions customize NewGenAIClientWithOptions. Unset fields keep the
+// defaults of NewGenAIClient.
+type ClientOptions struct {
+	// APIKey defaults to the GEMINI_API_KEY environment variable.
+	APIKey string
+	// HTTPClient sends the requests, for example through a cassette recorder.
+	HTTPClient *http.Client
+	// BaseURL points the client at another server, such as a local fake.
+	BaseURL string
+}
+
+// NewGenAIClientWithOptions creates a GenAI client that can be pointed at a
+// different transport or server, which is how tests run without network.
+func NewGenAIClientWithOptions(ctx context.Context, opts ClientOptions) (*genai.Client, error) {
+	if opts.APIKey == "" {
+		apiKey, ok := os.LookupEnv("GEMINI_API_KEY")
+		if !ok {
+			return nil, fmt.Errorf("environment variable GEMINI_API_KEY not set")
+		}
+		opts.APIKey = apiKey
+	}
+	return newGenAIClient(ctx, opts)
+}
+
 // newGenAIClientWithAPIKey is the internal implementation for creating a client.
 // It allows injecting the API key, which is useful for testing.
 func newGenAIClientWithAPIKey(ctx context.Context, apiKey string) (*genai.Client, error) {
-	if apiKey == "" {
+	return newGenAIClient(ctx, ClientOptions{APIKey: apiKey})
+}
+
+func newGenAIClient(ctx context.Context, opts ClientOptions) (*genai.Client, error) {
+	if opts.APIKey == "" {
 		return nil, fmt.Errorf("api key cannot be empty")
 	}
 
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
+		APIKey:      opts.APIKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPClient:  opts.HTTPClient,
+		HTTPOptions: genai.HTTPOptions{BaseURL: opts.BaseURL},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating client: %w", err)
@@ -0,0 +1,59 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// maxEmbedBatch is the largest number of contents the API accepts in a single embed request.
+const maxEmbedBatch = 100
+
+// TextEmbedder embeds plain text with a Gemini embedding model, using the
+// retrieval task types so documents and queries land in the same space.
+type TextEmbedder struct {
+	Embedder ContentEmbedder
+	Model    string
+}
+
+func (e *TextEmbedder) Name() string {
+	return e.Model
+}
+
+func (e *TextEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.embed(ctx, texts, "RETRIEVAL_DOCUMENT")
+}
+
+func (e *TextEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.embed(ctx, []string{text}, "RETRIEVAL_QUERY")
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (e *TextEmbedder) embed(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbedBatch {
+		end := min(start+maxEmbedBatch, len(texts))
+
+		contents := make([]*genai.Content, 0, end-start)
+		for _, text := range texts[start:end] {
+			contents = append(contents, genai.NewContentFromText(text, genai.RoleUser))
+		}
+
+		resp, err := e.Embedder.EmbedContent(ctx, e.Model, contents, &genai.EmbedContentConfig{TaskType: taskType})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed content: %w", err)
+		}
+		if len(resp.Embeddings) != len(contents) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(contents), len(resp.Embeddings))
+		}
+		for _, emb := range resp.Embeddings {
+			vectors = append(vectors, emb.Values)
+		}
+	}
+	return vectors, nil
+}
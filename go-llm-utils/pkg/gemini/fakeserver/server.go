@@ -0,0 +1,511 @@
+//revive:disable:package-comments,exported
+package fakeserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/geminitest"
+	"google.golang.org/genai"
+)
+
+// BaseURLEnv is read by the genai SDK. Setting it to Server.URL sends the
+// requests of code that creates its own client, such as the CLIs, to the fake.
+const BaseURLEnv = "GOOGLE_GEMINI_BASE_URL"
+
+// APIKey is the key a new Server accepts.
+const APIKey = "fake-api-key"
+
+// Endpoint identifies an API method served by the fake.
+type Endpoint string
+
+const (
+	ListModels            Endpoint = "models.list"
+	GetModel              Endpoint = "models.get"
+	GenerateContent       Endpoint = "generateContent"
+	StreamGenerateContent Endpoint = "streamGenerateContent"
+	CountTokens           Endpoint = "countTokens"
+	// EmbedContent is served as batchEmbedContents, the method the SDK's
+	// EmbedContent calls on the Gemini API.
+	EmbedContent Endpoint = "batchEmbedContents"
+)
+
+// Reply is a scripted answer to one request.
+type Reply struct {
+	// Status is the HTTP status; anything from 400 up is sent as an API
+	// error with Message. Zero means 200.
+	Status  int
+	Message string
+	// Latency delays the reply, on top of the server latency. Streams wait
+	// before every chunk.
+	Latency time.Duration
+
+	// Response answers generateContent, and streamGenerateContent as a
+	// single chunk unless Chunks is set.
+	Response *genai.GenerateContentResponse
+	Chunks   []*genai.GenerateContentResponse
+	// TotalTokens answers countTokens.
+	TotalTokens int32
+	// Embeddings answers embedContent, one vector per content.
+	Embeddings [][]float32
+}
+
+// Text replies with a single candidate that stops normally.
+func Text(text string) Reply {
+	return Reply{Response: geminitest.TextResponse(text)}
+}
+
+// TextChunks replies to a stream with one chunk per text; the last one stops.
+func TextChunks(texts ...string) Reply {
+	var r Reply
+	for i, text := range texts {
+		chunk := geminitest.TextResponse(text)
+		if i < len(texts)-1 {
+			chunk.Candidates[0].FinishReason = ""
+		}
+		r.Chunks = append(r.Chunks, chunk)
+	}
+	return r
+}
+
+// Error replies with an API error.
+func Error(status int, message string) Reply {
+	return Reply{Status: status, Message: message}
+}
+
+// RateLimited replies like the API does when the quota is exhausted.
+func RateLimited() Reply {
+	return Error(http.StatusTooManyRequests, "Resource has been exhausted (e.g. check quota).")
+}
+
+// InternalError replies like the API does when it fails.
+func InternalError() Reply {
+	return Error(http.StatusInternalServerError, "An internal error has occurred. Please retry or report in https://developers.generativeai.google/guide/troubleshooting")
+}
+
+// Blocked replies with a candidate stopped by the safety filter for category.
+func Blocked(category genai.HarmCategory) Reply {
+	return Reply{Response: geminitest.BlockedResponse(category)}
+}
+
+// DefaultModels are the models a new Server knows.
+func DefaultModels() []*genai.Model {
+	return []*genai.Model{
+		{
+			Name: "models/gemini-2.0-flash", Version: "001", DisplayName: "Gemini 2.0 Flash",
+			InputTokenLimit: 1048576, OutputTokenLimit: 8192,
+			SupportedActions: []string{"generateContent", "countTokens"},
+		},
+		{
+			Name: "models/gemini-2.5-pro", Version: "2.5", DisplayName: "Gemini 2.5 Pro",
+			InputTokenLimit: 1048576, OutputTokenLimit: 65536,
+			SupportedActions: []string{"generateContent", "countTokens"},
+		},
+		{
+			Name: "models/text-embedding-004", Version: "004", DisplayName: "Text Embedding 004",
+			InputTokenLimit: 2048, OutputTokenLimit: 1,
+			SupportedActions: []string{"embedContent"},
+		},
+		{
+			Name: "models/gemini-embedding-001", Version: "001", DisplayName: "Gemini Embedding 001",
+			InputTokenLimit: 2048, OutputTokenLimit: 1,
+			SupportedActions: []string{"embedContent"},
+		},
+	}
+}
+
+// Request is a request received by the fake.
+type Request struct {
+	Endpoint Endpoint
+	Model    string
+	Header   http.Header
+	Query    string
+	Body     []byte
+}
+
+// Prompt returns the text of the contents sent with the request.
+func (r Request) Prompt() string {
+	var body struct {
+		Contents []*genai.Content `json:"contents"`
+	}
+	if err := json.Unmarshal(r.Body, &body); err != nil {
+		return ""
+	}
+	return geminitest.Text(body.Contents)
+}
+
+// Server is an in-process stand-in for the Gemini API. Without a script it
+// serves its models, echoes prompts back, counts one token per word and
+// embeds each content as geminitest.Vector of its text; scripted replies are
+// used first, in order, per endpoint. The clients of this module do not retry
+// failed requests, so a scripted error such as RateLimited reaches the
+// caller as is; retrying is out of their scope.
+type Server struct {
+	*httptest.Server
+	// APIKey is the key clients must send. Change it before the first request.
+	APIKey string
+
+	mu       sync.Mutex
+	models   []*genai.Model
+	scripts  map[Endpoint][]Reply
+	latency  time.Duration
+	requests []Request
+}
+
+// New starts a fake server. Close it when done.
+func New() *Server {
+	s := &Server{APIKey: APIKey, models: DefaultModels(), scripts: map[Endpoint][]Reply{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client creates a genai client that talks to the server.
+func (s *Server) Client(ctx context.Context) (*genai.Client, error) {
+	return gemini.NewGenAIClientWithOptions(ctx, gemini.ClientOptions{APIKey: s.APIKey, BaseURL: s.URL})
+}
+
+// SetModels replaces the models the server knows.
+func (s *Server) SetModels(models ...*genai.Model) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.models = models
+}
+
+// SetLatency delays every reply.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Script queues replies for the next requests to endpoint.
+func (s *Server) Script(endpoint Endpoint, replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[endpoint] = append(s.scripts[endpoint], replies...)
+}
+
+// Pending returns the number of scripted replies to endpoint not sent yet.
+func (s *Server) Pending(endpoint Endpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.scripts[endpoint])
+}
+
+// Requests returns the requests received for endpoint, or all requests if
+// endpoint is empty.
+func (s *Server) Requests(endpoint Endpoint) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var requests []Request
+	for _, r := range s.requests {
+		if endpoint == "" || r.Endpoint == endpoint {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	endpoint, model, ok := route(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not served by the fake", r.Method, r.URL.Path))
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req := Request{Endpoint: endpoint, Model: model, Header: r.Header.Clone(), Query: r.URL.RawQuery, Body: body}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	if r.Header.Get("x-goog-api-key") != s.APIKey {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "API key not valid. Please pass a valid API key.")
+		return
+	}
+	reply, scripted := s.next(endpoint)
+	latency := s.latency + reply.Latency
+	known := model == "" || s.model(model) != nil
+	s.mu.Unlock()
+
+	if !sleep(r.Context(), latency) {
+		return
+	}
+	if reply.Status >= http.StatusBadRequest {
+		writeError(w, reply.Status, reply.Message)
+		return
+	}
+	if !known {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s is not found for API version v1beta, or is not supported for %s.", model, endpoint))
+		return
+	}
+
+	switch endpoint {
+	case ListModels:
+		s.listModels(w, r)
+	case GetModel:
+		s.mu.Lock()
+		m := s.model(model)
+		s.mu.Unlock()
+		writeJSON(w, toWire(m))
+	case GenerateContent:
+		writeJSON(w, s.response(reply, scripted, req))
+	case StreamGenerateContent:
+		s.stream(w, r, reply, scripted, req)
+	case CountTokens:
+		total := reply.TotalTokens
+		if !scripted || total == 0 {
+			total = words(req.Prompt())
+		}
+		writeJSON(w, map[string]any{"totalTokens": total})
+	case EmbedContent:
+		s.embed(w, reply, scripted, req)
+	}
+}
+
+// route maps a request to an endpoint and model name. The API version
+// prefix is ignored and duplicate slashes are tolerated.
+func route(r *http.Request) (Endpoint, string, bool) {
+	p := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+	if i := strings.Index(p, "models"); i >= 0 {
+		p = p[i:]
+	}
+	switch {
+	case p == "models" && r.Method == http.MethodGet:
+		return ListModels, "", true
+	case !strings.HasPrefix(p, "models/"):
+		return "", "", false
+	case r.Method == http.MethodGet:
+		return GetModel, p, true
+	case r.Method == http.MethodPost:
+		model, method, ok := strings.Cut(p, ":")
+		if !ok {
+			return "", "", false
+		}
+		switch endpoint := Endpoint(method); endpoint {
+		case GenerateContent, StreamGenerateContent, CountTokens, EmbedContent:
+			return endpoint, model, true
+		}
+	}
+	return "", "", false
+}
+
+// next pops the next scripted reply; s.mu must be held.
+func (s *Server) next(endpoint Endpoint) (Reply, bool) {
+	queue := s.scripts[endpoint]
+	if len(queue) == 0 {
+		return Reply{}, false
+	}
+	s.scripts[endpoint] = queue[1:]
+	return queue[0], true
+}
+
+// model finds a model by name; s.mu must be held.
+func (s *Server) model(name string) *genai.Model {
+	for _, m := range s.models {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *Server) listModels(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	models := s.models
+	s.mu.Unlock()
+
+	query := r.URL.Query()
+	start, _ := strconv.Atoi(query.Get("pageToken"))
+	start = min(max(start, 0), len(models))
+	end := len(models)
+	if size, err := strconv.Atoi(query.Get("pageSize")); err == nil && size > 0 {
+		end = min(start+size, len(models))
+	}
+
+	wire := make([]modelJSON, 0, end-start)
+	for _, m := range models[start:end] {
+		wire = append(wire, toWire(m))
+	}
+	page := map[string]any{"models": wire}
+	if end < len(models) {
+		page["nextPageToken"] = strconv.Itoa(end)
+	}
+	writeJSON(w, page)
+}
+
+// response is the scripted response, or an echo of the prompt. Missing
+// usage is filled in at one token per word.
+func (s *Server) response(reply Reply, scripted bool, req Request) *genai.GenerateContentResponse {
+	resp := reply.Response
+	if !scripted || resp == nil {
+		resp = geminitest.TextResponse("echo: " + req.Prompt())
+	}
+	return withUsage(resp, req)
+}
+
+// embed answers a batchEmbedContents request with the scripted vectors, or
+// with a deterministic vector of outputDimensionality (default 8) per content.
+func (s *Server) embed(w http.ResponseWriter, reply Reply, scripted bool, req Request) {
+	var body struct {
+		Requests []struct {
+			Content              *genai.Content `json:"content"`
+			OutputDimensionality int            `json:"outputDimensionality"`
+		} `json:"requests"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	vectors := reply.Embeddings
+	if !scripted || vectors == nil {
+		vectors = nil
+		for _, r := range body.Requests {
+			dims := r.OutputDimensionality
+			if dims <= 0 {
+				dims = 8
+			}
+			vectors = append(vectors, geminitest.Vector(geminitest.Text([]*genai.Content{r.Content}), dims))
+		}
+	}
+
+	embeddings := make([]map[string]any, len(vectors))
+	for i, v := range vectors {
+		embeddings[i] = map[string]any{"values": v}
+	}
+	writeJSON(w, map[string]any{"embeddings": embeddings})
+}
+
+func withUsage(resp *genai.GenerateContentResponse, req Request) *genai.GenerateContentResponse {
+	if resp.UsageMetadata != nil {
+		return resp
+	}
+	out := *resp
+	prompt := words(req.Prompt())
+	var candidates int32
+	for _, c := range resp.Candidates {
+		if c.Content == nil {
+			continue
+		}
+		for _, part := range c.Content.Parts {
+			candidates += words(part.Text)
+		}
+	}
+	out.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     prompt,
+		CandidatesTokenCount: candidates,
+		TotalTokenCount:      prompt + candidates,
+	}
+	if out.ModelVersion == "" {
+		out.ModelVersion = strings.TrimPrefix(req.Model, "models/")
+	}
+	return &out
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, reply Reply, scripted bool, req Request) {
+	chunks := reply.Chunks
+	if len(chunks) == 0 {
+		chunks = []*genai.GenerateContentResponse{s.response(reply, scripted, req)}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+	for i, chunk := range chunks {
+		if i > 0 && !sleep(r.Context(), reply.Latency) {
+			return
+		}
+		data, err := json.Marshal(withUsage(chunk, req))
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// modelJSON is a model as the API sends it; genai.Model names some fields differently.
+type modelJSON struct {
+	Name                       string   `json:"name"`
+	Version                    string   `json:"version,omitempty"`
+	DisplayName                string   `json:"displayName,omitempty"`
+	Description                string   `json:"description,omitempty"`
+	InputTokenLimit            int32    `json:"inputTokenLimit,omitempty"`
+	OutputTokenLimit           int32    `json:"outputTokenLimit,omitempty"`
+	SupportedGenerationMethods []string `json:"supportedGenerationMethods,omitempty"`
+}
+
+func toWire(m *genai.Model) modelJSON {
+	return modelJSON{
+		Name:                       m.Name,
+		Version:                    m.Version,
+		DisplayName:                m.DisplayName,
+		Description:                m.Description,
+		InputTokenLimit:            m.InputTokenLimit,
+		OutputTokenLimit:           m.OutputTokenLimit,
+		SupportedGenerationMethods: m.SupportedActions,
+	}
+}
+
+// statusNames are the gRPC status names the API reports with HTTP errors.
+var statusNames = map[int]string{
+	http.StatusBadRequest:          "INVALID_ARGUMENT",
+	http.StatusForbidden:           "PERMISSION_DENIED",
+	http.StatusNotFound:            "NOT_FOUND",
+	http.StatusTooManyRequests:     "RESOURCE_EXHAUSTED",
+	http.StatusInternalServerError: "INTERNAL",
+	http.StatusServiceUnavailable:  "UNAVAILABLE",
+	http.StatusGatewayTimeout:      "DEADLINE_EXCEEDED",
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": status, "message": message, "status": statusNames[status]},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// sleep waits for d and reports whether the request is still wanted.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func words(s string) int32 {
+	return int32(len(strings.Fields(s)))
+}
@@ -0,0 +1,191 @@
+package fakeserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/geminitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+const model = "models/gemini-2.0-flash"
+
+func start(t *testing.T) (*Server, *genai.Client) {
+	t.Helper()
+	s := New()
+	t.Cleanup(s.Close)
+	client, err := s.Client(context.Background())
+	require.NoError(t, err)
+	return s, client
+}
+
+func TestModels(t *testing.T) {
+	t.Parallel()
+	s, client := start(t)
+	ctx := context.Background()
+
+	page, err := client.Models.List(ctx, &genai.ListModelsConfig{PageSize: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "2", page.NextPageToken)
+	assert.Equal(t, []string{"generateContent", "countTokens"}, page.Items[0].SupportedActions)
+
+	var names []string
+	for m, err := range client.Models.All(ctx) {
+		require.NoError(t, err)
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"models/gemini-2.0-flash", "models/gemini-2.5-pro", "models/text-embedding-004", "models/gemini-embedding-001"}, names)
+
+	m, err := gemini.ModelsGet(ctx, &gemini.GenAIModelGetter{Client: client}, "gemini-2.5-pro")
+	require.NoError(t, err)
+	assert.Equal(t, "Gemini 2.5 Pro", m.DisplayName)
+	assert.Equal(t, int32(65536), m.OutputTokenLimit)
+
+	_, err = client.Models.Get(ctx, "models/unknown", nil)
+	var apiErr genai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Code)
+	assert.Len(t, s.Requests(GetModel), 2)
+}
+
+func TestGenerateContentEchoes(t *testing.T) {
+	t.Parallel()
+	s, client := start(t)
+
+	resp, err := client.Models.GenerateContent(context.Background(), model, genai.Text("Say hello"), nil)
+	require.NoError(t, err)
+	text, err := gemini.ResponseText(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "echo: Say hello", text)
+	assert.Equal(t, int32(2), resp.UsageMetadata.PromptTokenCount)
+	assert.Equal(t, int32(3), resp.UsageMetadata.CandidatesTokenCount)
+	assert.Equal(t, "gemini-2.0-flash", resp.ModelVersion)
+
+	requests := s.Requests(GenerateContent)
+	require.Len(t, requests, 1)
+	assert.Equal(t, model, requests[0].Model)
+	assert.Equal(t, "Say hello", requests[0].Prompt())
+}
+
+func TestScriptedErrorsThenSuccess(t *testing.T) {
+	t.Parallel()
+	s, client := start(t)
+	s.Script(GenerateContent, RateLimited(), InternalError(), Text("finally"))
+	ctx := context.Background()
+
+	var codes []int
+	for range 3 {
+		resp, err := client.Models.GenerateContent(ctx, model, genai.Text("try"), nil)
+		var apiErr genai.APIError
+		if errors.As(err, &apiErr) {
+			codes = append(codes, apiErr.Code)
+			continue
+		}
+		require.NoError(t, err)
+		text, err := gemini.ResponseText(resp)
+		require.NoError(t, err)
+		assert.Equal(t, "finally", text)
+	}
+	assert.Equal(t, []int{http.StatusTooManyRequests, http.StatusInternalServerError}, codes)
+	assert.Zero(t, s.Pending(GenerateContent))
+}
+
+func TestBlocked(t *testing.T) {
+	t.Parallel()
+	s, client := start(t)
+	s.Script(GenerateContent, Blocked(genai.HarmCategoryHarassment))
+
+	resp, err := client.Models.GenerateContent(context.Background(), model, genai.Text("insult me"), nil)
+	require.NoError(t, err)
+	_, err = gemini.ResponseText(resp)
+	assert.ErrorIs(t, err, gemini.ErrBlocked)
+	assert.ErrorContains(t, err, "HARASSMENT")
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+	s, client := start(t)
+	s.Script(StreamGenerateContent, TextChunks("Hel", "lo", "!"))
+
+	var text strings.Builder
+	var last *genai.GenerateContentResponse
+	for resp, err := range client.Models.GenerateContentStream(context.Background(), model, genai.Text("hi"), nil) {
+		require.NoError(t, err)
+		text.WriteString(resp.Text())
+		last = resp
+	}
+	assert.Equal(t, "Hello!", text.String())
+	require.NotNil(t, last)
+	assert.Equal(t, genai.FinishReasonStop, last.Candidates[0].FinishReason)
+}
+
+func TestCountTokens(t *testing.T) {
+	t.Parallel()
+	s, client := start(t)
+	ctx := context.Background()
+
+	resp, err := client.Models.CountTokens(ctx, model, genai.Text("one two three"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), resp.TotalTokens)
+
+	s.Script(CountTokens, Reply{TotalTokens: 42})
+	resp, err = client.Models.CountTokens(ctx, model, genai.Text("one"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(42), resp.TotalTokens)
+}
+
+func TestEmbedContent(t *testing.T) {
+	t.Parallel()
+	s, client := start(t)
+	ctx := context.Background()
+	const embedModel = "models/text-embedding-004"
+
+	contents := []*genai.Content{genai.NewContentFromText("alpha", genai.RoleUser), genai.NewContentFromText("beta", genai.RoleUser)}
+	resp, err := client.Models.EmbedContent(ctx, embedModel, contents, &genai.EmbedContentConfig{OutputDimensionality: genai.Ptr[int32](4)})
+	require.NoError(t, err)
+	require.Len(t, resp.Embeddings, 2)
+	assert.Equal(t, geminitest.Vector("alpha", 4), resp.Embeddings[0].Values)
+	assert.Equal(t, geminitest.Vector("beta", 4), resp.Embeddings[1].Values)
+	require.Len(t, s.Requests(EmbedContent), 1)
+	assert.Equal(t, embedModel, s.Requests(EmbedContent)[0].Model)
+
+	s.Script(EmbedContent, RateLimited(), Reply{Embeddings: [][]float32{{1, 0}}})
+	_, err = client.Models.EmbedContent(ctx, embedModel, contents[:1], nil)
+	var apiErr genai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.Code, "the client does not retry")
+	resp, err = client.Models.EmbedContent(ctx, embedModel, contents[:1], nil)
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1, 0}, resp.Embeddings[0].Values)
+}
+
+func TestLatency(t *testing.T) {
+	t.Parallel()
+	s, client := start(t)
+	s.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.Models.GenerateContent(ctx, model, genai.Text("slow"), nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWrongAPIKey(t *testing.T) {
+	t.Parallel()
+	s, _ := start(t)
+	s.Script(GenerateContent, Text("never sent"))
+
+	client, err := gemini.NewGenAIClientWithOptions(context.Background(), gemini.ClientOptions{APIKey: "wrong", BaseURL: s.URL})
+	require.NoError(t, err)
+	_, err = client.Models.GenerateContent(context.Background(), model, genai.Text("hi"), nil)
+	assert.ErrorContains(t, err, "API key not valid")
+	assert.Equal(t, 1, s.Pending(GenerateContent), "a rejected request does not use the script")
+}
@@ -0,0 +1,182 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/fsutil"
+)
+
+// ExistsMode says what WriteFile does when the file already exists.
+type ExistsMode int
+
+const (
+	// Overwrite replaces the file.
+	Overwrite ExistsMode = iota
+	// Fail refuses to touch the file and returns an error wrapping fs.ErrExist.
+	Fail
+	// Backup keeps the previous contents as <file>.1, <file>.2 and so on,
+	// the newest first.
+	Backup
+	// Timestamp leaves the file alone and writes to <name>-<UTC time><ext> instead.
+	Timestamp
+)
+
+// DefaultBackups is the number of backups kept when FileOptions.Backups is not set.
+const DefaultBackups = 3
+
+var existsModes = map[string]ExistsMode{
+	"overwrite": Overwrite,
+	"fail":      Fail,
+	"backup":    Backup,
+	"timestamp": Timestamp,
+}
+
+// ExistsModes lists the names accepted by ParseExistsMode.
+func ExistsModes() []string {
+	return []string{"overwrite", "fail", "backup", "timestamp"}
+}
+
+// ParseExistsMode returns the mode with the given name.
+func ParseExistsMode(name string) (ExistsMode, error) {
+	mode, ok := existsModes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown mode %q for existing files (available: %s)", name, strings.Join(ExistsModes(), ", "))
+	}
+	return mode, nil
+}
+
+// FileOptions controls how response files are created.
+type FileOptions struct {
+	// Perm is the mode of new files; it defaults to 0644.
+	Perm os.FileMode
+	// IfExists decides what happens to an existing file.
+	IfExists ExistsMode
+	// Backups is the number of backups kept in Backup mode; it defaults to DefaultBackups.
+	Backups int
+	// Now names files in Timestamp mode; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// WriteFile writes data to a temporary file next to filename and renames it
+// into place, so readers never see a partial file and a crash leaves the
+// previous contents intact. It returns the file written, which differs from
+// filename in Timestamp mode.
+func WriteFile(filename string, data []byte, opts FileOptions) (string, error) {
+	perm := opts.Perm
+	if perm == 0 {
+		perm = 0644
+	}
+
+	tmp, err := fsutil.WriteTemp(filename, data, perm)
+	if err != nil {
+		return "", fmt.Errorf("failed to write %q: %w", filename, err)
+	}
+	defer os.Remove(tmp)
+
+	switch opts.IfExists {
+	case Fail:
+		err = linkNew(tmp, filename)
+	case Timestamp:
+		filename, err = linkTimestamped(tmp, filename, opts.Now)
+	case Backup:
+		backups := opts.Backups
+		if backups <= 0 {
+			backups = DefaultBackups
+		}
+		if err = rotateBackups(filename, backups); err == nil {
+			err = os.Rename(tmp, filename)
+		}
+	default:
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write %q: %w", filename, err)
+	}
+	return filename, nil
+}
+
+// linkNew moves tmp to filename only if filename does not exist. A hard link
+// makes the check and the creation one step; file systems without hard links
+// fall back to a check followed by a rename.
+func linkNew(tmp, filename string) error {
+	err := os.Link(tmp, filename)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return fs.ErrExist
+	}
+
+	if _, statErr := os.Lstat(filename); statErr == nil {
+		return fs.ErrExist
+	} else if !errors.Is(statErr, fs.ErrNotExist) {
+		return statErr
+	}
+	return os.Rename(tmp, filename)
+}
+
+// linkTimestamped moves tmp to filename with the current time inserted
+// before the extension, adding a counter if that name is taken as well.
+func linkTimestamped(tmp, filename string, now func() time.Time) (string, error) {
+	if now == nil {
+		now = time.Now
+	}
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext) + "-" + now().UTC().Format("20060102T150405Z")
+
+	for i := 1; ; i++ {
+		name := base + ext
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		err := linkNew(tmp, name)
+		if !errors.Is(err, fs.ErrExist) {
+			return name, err
+		}
+	}
+}
+
+// rotateBackups shifts <file>.1 ... <file>.<keep-1> up by one, dropping the
+// oldest, and keeps the current file as <file>.1. The current file stays in
+// place, so only the final rename replaces it.
+func rotateBackups(filename string, keep int) error {
+	if _, err := os.Lstat(filename); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	backup := func(i int) string { return fmt.Sprintf("%s.%d", filename, i) }
+	if err := os.Remove(backup(keep)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for i := keep - 1; i >= 1; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := os.Link(filename, backup(1)); err == nil {
+		return nil
+	}
+	return copyFile(filename, backup(1))
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, info.Mode().Perm())
+}
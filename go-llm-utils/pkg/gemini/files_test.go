@@ -0,0 +1,128 @@
+package gemini
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFile(t *testing.T, filename string) string {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// dirEntries lists the file names in dir, to check that no temporary files are left behind.
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWriteFileOverwrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "general-response.md")
+
+	written, err := WriteFile(filename, []byte("first"), FileOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, filename, written)
+	_, err = WriteFile(filename, []byte("second"), FileOptions{Perm: 0600})
+	require.NoError(t, err)
+
+	assert.Equal(t, "second", readFile(t, filename))
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Equal(t, []string{"general-response.md"}, dirEntries(t, dir))
+}
+
+func TestWriteFileFail(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "general-response.md")
+
+	_, err := WriteFile(filename, []byte("first"), FileOptions{IfExists: Fail})
+	require.NoError(t, err)
+	_, err = WriteFile(filename, []byte("second"), FileOptions{IfExists: Fail})
+	require.ErrorIs(t, err, fs.ErrExist)
+	assert.EqualError(t, err, `failed to write "`+filename+`": file already exists`)
+
+	assert.Equal(t, "first", readFile(t, filename))
+	assert.Equal(t, []string{"general-response.md"}, dirEntries(t, dir))
+}
+
+func TestWriteFileBackup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "r.md")
+
+	for _, text := range []string{"one", "two", "three", "four"} {
+		_, err := WriteFile(filename, []byte(text), FileOptions{IfExists: Backup, Backups: 2})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "four", readFile(t, filename))
+	assert.Equal(t, "three", readFile(t, filename+".1"))
+	assert.Equal(t, "two", readFile(t, filename+".2"))
+	assert.Equal(t, []string{"r.md", "r.md.1", "r.md.2"}, dirEntries(t, dir), "the oldest backup is dropped")
+}
+
+func TestWriteFileTimestamp(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "r.md")
+	require.NoError(t, os.WriteFile(filename, []byte("original"), 0644))
+	now := func() time.Time { return time.Date(2025, 7, 1, 14, 30, 5, 0, time.FixedZone("CEST", 2*3600)) }
+
+	first, err := WriteFile(filename, []byte("one"), FileOptions{IfExists: Timestamp, Now: now})
+	require.NoError(t, err)
+	second, err := WriteFile(filename, []byte("two"), FileOptions{IfExists: Timestamp, Now: now})
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dir, "r-20250701T123005Z.md"), first)
+	assert.Equal(t, filepath.Join(dir, "r-20250701T123005Z-2.md"), second)
+	assert.Equal(t, "original", readFile(t, filename))
+	assert.Equal(t, "one", readFile(t, first))
+	assert.Equal(t, "two", readFile(t, second))
+	assert.Len(t, dirEntries(t, dir), 3)
+}
+
+func TestWriteFileMissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	_, err := WriteFile(filepath.Join(t.TempDir(), "missing", "r.md"), []byte("x"), FileOptions{})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestParseExistsMode(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range ExistsModes() {
+		_, err := ParseExistsMode(name)
+		assert.NoError(t, err, name)
+	}
+	mode, err := ParseExistsMode("backup")
+	require.NoError(t, err)
+	assert.Equal(t, Backup, mode)
+
+	_, err = ParseExistsMode("clobber")
+	assert.EqualError(t, err, `unknown mode "clobber" for existing files (available: overwrite, fail, backup, timestamp)`)
+}
@@ -7,12 +7,12 @@ import (
 	"google.golang.org/genai"
 )
 
-// FilterModelsByAction returns model names that support the given action.
-func FilterModelsByAction(models []*genai.Model, action string) []string {
-	var result []string
+// FilterModelsByAction returns the models that support the given action.
+func FilterModelsByAction(models []*genai.Model, action string) []*genai.Model {
+	var result []*genai.Model
 	for _, m := range models {
 		if slices.Contains(m.SupportedActions, action) {
-			result = append(result, m.Name)
+			result = append(result, m)
 		}
 	}
 	return result
@@ -32,8 +32,8 @@ func TestFilterModelsByAction(t *testing.T) {
 			}
 
 			for i := range tt.expected {
-				if got[i] != tt.expected[i] {
-					t.Errorf("at index %d: expected %q, got %q", i, tt.expected[i], got[i])
+				if got[i].Name != tt.expected[i] {
+					t.Errorf("at index %d: expected %q, got %q", i, tt.expected[i], got[i].Name)
 				}
 			}
 		})
@@ -0,0 +1,448 @@
+//revive:disable:package-comments,exported
+
+// Package geminitest provides in-memory fakes for the interfaces in package
+// gemini: Models (ModelLister and ModelGetter), Generator
+// (ContentGenerator), Counter (TokenCounter) and Embedder
+// (ContentEmbedder). Each fake answers from a script of queued results
+// first, then from its fallback, records every call and has assertion
+// helpers for the calls and the script.
+//
+// The package depends only on genai, so the tests of package gemini can use
+// it too.
+package geminitest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+// result is one scripted answer.
+type result[R any] struct {
+	value R
+	err   error
+}
+
+// fake holds the script and the calls of a fake with call type C and result type R.
+type fake[C, R any] struct {
+	mu     sync.Mutex
+	script []result[R]
+	calls  []C
+}
+
+func (f *fake[C, R]) push(value R, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.script = append(f.script, result[R]{value, err})
+}
+
+// call records c and pops the next scripted result; ok is false when the
+// script is exhausted.
+func (f *fake[C, R]) call(c C) (value R, err error, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, c)
+	if len(f.script) == 0 {
+		return value, nil, false
+	}
+	next := f.script[0]
+	f.script = f.script[1:]
+	return next.value, next.err, true
+}
+
+// name names the fake in messages after its call type.
+func (f *fake[C, R]) name() string {
+	var c C
+	return strings.TrimPrefix(fmt.Sprintf("%T", c), "geminitest.")
+}
+
+// exhausted is the error of a call the script does not cover and no fallback answers.
+func (f *fake[C, R]) exhausted() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fmt.Errorf("geminitest: unexpected %s call %d: script exhausted", f.name(), len(f.calls))
+}
+
+// Calls returns the recorded calls in order.
+func (f *fake[C, R]) Calls() []C {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]C(nil), f.calls...)
+}
+
+// Remaining returns the number of scripted results not used yet.
+func (f *fake[C, R]) Remaining() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.script)
+}
+
+// AssertCalls checks the number of recorded calls.
+func (f *fake[C, R]) AssertCalls(t testing.TB, n int) bool {
+	t.Helper()
+	if got := len(f.Calls()); got != n {
+		t.Errorf("geminitest: expected %d %s calls, got %d", n, f.name(), got)
+		return false
+	}
+	return true
+}
+
+// AssertDone checks that every scripted result was used.
+func (f *fake[C, R]) AssertDone(t testing.TB) bool {
+	t.Helper()
+	if n := f.Remaining(); n > 0 {
+		t.Errorf("geminitest: %d scripted %s results were not used", n, f.name())
+		return false
+	}
+	return true
+}
+
+// Text joins the text parts of contents with newlines, which is what most
+// assertions about a prompt want to compare.
+func Text(contents []*genai.Content) string {
+	var texts []string
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		for _, part := range content.Parts {
+			if part != nil && part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// TextResponse is a response with a single candidate that stops normally.
+func TextResponse(text string) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content:      genai.NewContentFromText(text, genai.RoleModel),
+			FinishReason: genai.FinishReasonStop,
+		}},
+	}
+}
+
+// BlockedResponse is a response whose candidate the safety filter stopped for category.
+func BlockedResponse(category genai.HarmCategory) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			FinishReason: genai.FinishReasonSafety,
+			SafetyRatings: []*genai.SafetyRating{{
+				Category:    category,
+				Probability: genai.HarmProbabilityHigh,
+				Blocked:     true,
+			}},
+		}},
+	}
+}
+
+// NotFound is the error the API returns for an unknown resource.
+func NotFound(name string) error {
+	return genai.APIError{Code: 404, Message: fmt.Sprintf("%s is not found", name), Status: "NOT_FOUND"}
+}
+
+// GenerateCall is a recorded GenerateContent call.
+type GenerateCall struct {
+	Model    string
+	Contents []*genai.Content
+	Config   *genai.GenerateContentConfig
+}
+
+// Prompt returns the text of the call's contents.
+func (c GenerateCall) Prompt() string { return Text(c.Contents) }
+
+// Generator is a fake ContentGenerator.
+type Generator struct {
+	fake[GenerateCall, *genai.GenerateContentResponse]
+	// Fallback answers calls once the script is exhausted; nil fails them.
+	Fallback func(GenerateCall) (*genai.GenerateContentResponse, error)
+}
+
+// NewGenerator returns a generator that answers with the given texts in order.
+func NewGenerator(texts ...string) *Generator {
+	g := &Generator{}
+	for _, text := range texts {
+		g.RespondText(text)
+	}
+	return g
+}
+
+// Echo is a Generator fallback that answers every prompt with "echo: <prompt>".
+func Echo(c GenerateCall) (*genai.GenerateContentResponse, error) {
+	return TextResponse("echo: " + c.Prompt()), nil
+}
+
+// Respond queues responses.
+func (g *Generator) Respond(responses ...*genai.GenerateContentResponse) *Generator {
+	for _, resp := range responses {
+		g.push(resp, nil)
+	}
+	return g
+}
+
+// RespondText queues text responses.
+func (g *Generator) RespondText(texts ...string) *Generator {
+	for _, text := range texts {
+		g.push(TextResponse(text), nil)
+	}
+	return g
+}
+
+// Fail queues errors.
+func (g *Generator) Fail(errs ...error) *Generator {
+	for _, err := range errs {
+		g.push(nil, err)
+	}
+	return g
+}
+
+func (g *Generator) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c := GenerateCall{Model: model, Contents: contents, Config: config}
+	if resp, err, ok := g.call(c); ok {
+		return resp, err
+	}
+	if g.Fallback != nil {
+		return g.Fallback(c)
+	}
+	return nil, g.exhausted()
+}
+
+// Prompts returns the prompt text of every call.
+func (g *Generator) Prompts() []string {
+	var prompts []string
+	for _, c := range g.Calls() {
+		prompts = append(prompts, c.Prompt())
+	}
+	return prompts
+}
+
+// AssertPrompts checks the prompt text of every call, in order.
+func (g *Generator) AssertPrompts(t testing.TB, want ...string) bool {
+	t.Helper()
+	got := g.Prompts()
+	if len(got) != len(want) {
+		t.Errorf("geminitest: expected prompts %q, got %q", want, got)
+		return false
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("geminitest: expected prompts %q, got %q", want, got)
+			return false
+		}
+	}
+	return true
+}
+
+// ModelsCall is a recorded ListModels or Get call.
+type ModelsCall struct {
+	Method string
+	Name   string
+}
+
+// Models is a fake ModelLister and ModelGetter serving a fixed set of
+// models. Scripted errors fail the next calls of either kind.
+type Models struct {
+	fake[ModelsCall, struct{}]
+	Models []*genai.Model
+}
+
+// NewModels returns a fake that knows the given models.
+func NewModels(models ...*genai.Model) *Models {
+	return &Models{Models: models}
+}
+
+// Fail queues errors.
+func (m *Models) Fail(errs ...error) *Models {
+	for _, err := range errs {
+		m.push(struct{}{}, err)
+	}
+	return m
+}
+
+// ListModels pages through the models, honouring the page size and token of config.
+func (m *Models) ListModels(ctx context.Context, config *genai.ListModelsConfig) (genai.Page[genai.Model], error) {
+	var page genai.Page[genai.Model]
+	if err := ctx.Err(); err != nil {
+		return page, err
+	}
+	if _, err, ok := m.call(ModelsCall{Method: "ListModels"}); ok && err != nil {
+		return page, err
+	}
+
+	start, end := 0, len(m.Models)
+	if config != nil {
+		if config.PageToken != "" {
+			if _, err := fmt.Sscan(config.PageToken, &start); err != nil || start < 0 || start > len(m.Models) {
+				return page, fmt.Errorf("geminitest: invalid page token %q", config.PageToken)
+			}
+		}
+		if config.PageSize > 0 {
+			end = min(start+int(config.PageSize), len(m.Models))
+		}
+	}
+	page.Items = m.Models[start:end]
+	if end < len(m.Models) {
+		page.NextPageToken = fmt.Sprint(end)
+	}
+	return page, nil
+}
+
+// Get returns the model with the given name, with or without the "models/" prefix.
+func (m *Models) Get(ctx context.Context, name string, _ *genai.GetModelConfig) (*genai.Model, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, err, ok := m.call(ModelsCall{Method: "Get", Name: name}); ok && err != nil {
+		return nil, err
+	}
+	full := name
+	if !strings.HasPrefix(full, "models/") {
+		full = "models/" + full
+	}
+	for _, model := range m.Models {
+		if model.Name == full {
+			return model, nil
+		}
+	}
+	return nil, NotFound(name)
+}
+
+// CountCall is a recorded CountTokens call.
+type CountCall struct {
+	Model    string
+	Contents []*genai.Content
+	Config   *genai.CountTokensConfig
+}
+
+// Counter is a fake TokenCounter. Once the script is exhausted it counts
+// one token per word.
+type Counter struct {
+	fake[CountCall, int32]
+}
+
+// NewCounter returns a counter that answers with the given totals in order.
+func NewCounter(totals ...int32) *Counter {
+	c := &Counter{}
+	for _, total := range totals {
+		c.push(total, nil)
+	}
+	return c
+}
+
+// Fail queues errors.
+func (c *Counter) Fail(errs ...error) *Counter {
+	for _, err := range errs {
+		c.push(0, err)
+	}
+	return c
+}
+
+func (c *Counter) CountTokens(ctx context.Context, model string, contents []*genai.Content, config *genai.CountTokensConfig) (*genai.CountTokensResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	call := CountCall{Model: model, Contents: contents, Config: config}
+	total, err, ok := c.call(call)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		total = int32(len(strings.Fields(Text(contents))))
+	}
+	return &genai.CountTokensResponse{TotalTokens: total}, nil
+}
+
+// EmbedCall is a recorded EmbedContent call.
+type EmbedCall struct {
+	Model    string
+	Contents []*genai.Content
+	Config   *genai.EmbedContentConfig
+}
+
+// Embedder is a fake ContentEmbedder. Once the script is exhausted it
+// embeds each content with Vector, so equal texts get equal vectors.
+type Embedder struct {
+	fake[EmbedCall, [][]float32]
+	// Dimensions of the vectors; zero means 8.
+	Dimensions int
+}
+
+// NewEmbedder returns an embedder that answers with the given vectors, one
+// call each.
+func NewEmbedder(responses ...[][]float32) *Embedder {
+	e := &Embedder{}
+	for _, vectors := range responses {
+		e.push(vectors, nil)
+	}
+	return e
+}
+
+// Fail queues errors.
+func (e *Embedder) Fail(errs ...error) *Embedder {
+	for _, err := range errs {
+		e.push(nil, err)
+	}
+	return e
+}
+
+func (e *Embedder) EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	vectors, err, ok := e.call(EmbedCall{Model: model, Contents: contents, Config: config})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		dims := e.Dimensions
+		if dims == 0 {
+			dims = 8
+		}
+		for _, content := range contents {
+			vectors = append(vectors, Vector(Text([]*genai.Content{content}), dims))
+		}
+	}
+	resp := &genai.EmbedContentResponse{}
+	for _, v := range vectors {
+		resp.Embeddings = append(resp.Embeddings, &genai.ContentEmbedding{Values: v})
+	}
+	return resp, nil
+}
+
+// Vector is a deterministic unit vector for text: equal texts get equal
+// vectors and different texts almost surely do not.
+func Vector(text string, dims int) []float32 {
+	v := make([]float32, dims)
+	// FNV-1a over the text seeds a xorshift generator.
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(text); i++ {
+		h ^= uint64(text[i])
+		h *= 1099511628211
+	}
+	var norm float64
+	for i := range v {
+		h ^= h << 13
+		h ^= h >> 7
+		h ^= h << 17
+		v[i] = float32(int64(h%2001)-1000) / 1000
+		norm += float64(v[i]) * float64(v[i])
+	}
+	if norm == 0 {
+		v[0], norm = 1, 1
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+	return v
+}
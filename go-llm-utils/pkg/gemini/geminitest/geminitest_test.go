@@ -0,0 +1,170 @@
+package geminitest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini"
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/gemini/geminitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+var (
+	_ gemini.ModelLister      = (*geminitest.Models)(nil)
+	_ gemini.ModelGetter      = (*geminitest.Models)(nil)
+	_ gemini.ContentGenerator = (*geminitest.Generator)(nil)
+	_ gemini.TokenCounter     = (*geminitest.Counter)(nil)
+	_ gemini.ContentEmbedder  = (*geminitest.Embedder)(nil)
+)
+
+// failures captures the errors an assertion helper reports.
+type failures struct {
+	testing.TB
+	errors int
+}
+
+func (f *failures) Helper() {}
+
+func (f *failures) Errorf(string, ...any) { f.errors++ }
+
+func TestGeneratorScript(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	quota := errors.New("quota exceeded")
+	gen := geminitest.NewGenerator("first").Fail(quota).Respond(geminitest.BlockedResponse(genai.HarmCategoryHarassment))
+
+	resp, err := gen.GenerateContent(ctx, "models/m", genai.Text("one"), nil)
+	require.NoError(t, err)
+	text, err := gemini.ResponseText(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "first", text)
+
+	_, err = gen.GenerateContent(ctx, "models/m", genai.Text("two"), nil)
+	assert.ErrorIs(t, err, quota)
+
+	resp, err = gen.GenerateContent(ctx, "models/m", genai.Text("three"), nil)
+	require.NoError(t, err)
+	_, err = gemini.ResponseText(resp)
+	assert.ErrorIs(t, err, gemini.ErrBlocked)
+
+	_, err = gen.GenerateContent(ctx, "models/m", genai.Text("four"), nil)
+	assert.EqualError(t, err, "geminitest: unexpected GenerateCall call 4: script exhausted")
+
+	gen.AssertCalls(t, 4)
+	gen.AssertDone(t)
+	gen.AssertPrompts(t, "one", "two", "three", "four")
+	assert.Equal(t, "models/m", gen.Calls()[0].Model)
+}
+
+func TestGeneratorFallback(t *testing.T) {
+	t.Parallel()
+
+	gen := &geminitest.Generator{Fallback: geminitest.Echo}
+	config := &genai.GenerateContentConfig{Seed: gemini.I32(5)}
+	resp, err := gen.GenerateContent(context.Background(), "models/m", genai.Text("hello"), config)
+	require.NoError(t, err)
+	text, err := gemini.ResponseText(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "echo: hello", text)
+	assert.Same(t, config, gen.Calls()[0].Config)
+}
+
+func TestAssertionHelpersReport(t *testing.T) {
+	t.Parallel()
+
+	gen := geminitest.NewGenerator("unused")
+	f := &failures{TB: t}
+	assert.False(t, gen.AssertCalls(f, 1))
+	assert.False(t, gen.AssertDone(f))
+	assert.False(t, gen.AssertPrompts(f, "missing"))
+	assert.Equal(t, 3, f.errors)
+}
+
+func TestModels(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	models := geminitest.NewModels(
+		&genai.Model{Name: "models/a"},
+		&genai.Model{Name: "models/b"},
+		&genai.Model{Name: "models/c"},
+	)
+
+	page, err := models.ListModels(ctx, &genai.ListModelsConfig{PageSize: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	page, err = models.ListModels(ctx, &genai.ListModelsConfig{PageSize: 2, PageToken: page.NextPageToken})
+	require.NoError(t, err)
+	assert.Equal(t, "models/c", page.Items[0].Name)
+	assert.Empty(t, page.NextPageToken)
+
+	m, err := gemini.ModelsGet(ctx, models, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "models/b", m.Name)
+
+	_, err = models.Get(ctx, "models/x", nil)
+	var apiErr genai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 404, apiErr.Code)
+
+	models.Fail(errors.New("unavailable"))
+	_, err = models.Get(ctx, "models/a", nil)
+	assert.EqualError(t, err, "unavailable")
+	assert.Equal(t, geminitest.ModelsCall{Method: "Get", Name: "b"}, models.Calls()[2])
+}
+
+func TestCounter(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	counter := geminitest.NewCounter(100)
+	resp, err := counter.CountTokens(ctx, "models/m", genai.Text("ignored"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), resp.TotalTokens)
+	resp, err = counter.CountTokens(ctx, "models/m", genai.Text("one two three"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), resp.TotalTokens, "one token per word once the script is used")
+	counter.AssertCalls(t, 2)
+}
+
+func TestEmbedder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	embedder := geminitest.NewEmbedder([][]float32{{1, 0}})
+	te := &gemini.TextEmbedder{Embedder: embedder, Model: "models/text-embedding-004"}
+
+	v, err := te.EmbedQuery(ctx, "scripted")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1, 0}, v)
+
+	vectors, err := te.EmbedDocuments(ctx, []string{"same", "other", "same"})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	assert.Len(t, vectors[0], 8)
+	assert.Equal(t, vectors[0], vectors[2])
+	assert.NotEqual(t, vectors[0], vectors[1])
+	assert.Equal(t, geminitest.Vector("same", 8), vectors[0])
+
+	var norm float32
+	for _, x := range vectors[1] {
+		norm += x * x
+	}
+	assert.InDelta(t, 1, norm, 1e-5)
+	assert.Equal(t, "RETRIEVAL_DOCUMENT", embedder.Calls()[1].Config.TaskType)
+}
+
+func TestCanceledContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gen := geminitest.NewGenerator("never")
+	_, err := gen.GenerateContent(ctx, "models/m", genai.Text("x"), nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	gen.AssertCalls(t, 0)
+}
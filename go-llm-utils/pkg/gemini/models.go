@@ -38,3 +38,69 @@ type GenAIModelGetter struct {
 func (g *GenAIModelGetter) Get(ctx context.Context, modelName string, config *genai.GetModelConfig) (*genai.Model, error) {
 	return g.Client.Models.Get(ctx, modelName, (*genai.GetModelConfig)(config))
 }
+
+// ContentEmbedder defines the interface for computing embeddings.
+type ContentEmbedder interface {
+	EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error)
+}
+
+// GenAIContentEmbedder is an adapter for genai.Client.Models
+type GenAIContentEmbedder struct {
+	Client *genai.Client
+}
+
+func (g *GenAIContentEmbedder) EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error) {
+	return g.Client.Models.EmbedContent(ctx, model, contents, config)
+}
+
+// ContentGenerator defines the interface for generating content.
+type ContentGenerator interface {
+	GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+}
+
+// GenAIContentGenerator is an adapter for genai.Client.Models
+type GenAIContentGenerator struct {
+	Client *genai.Client
+}
+
+func (g *GenAIContentGenerator) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	return g.Client.Models.GenerateContent(ctx, model, contents, config)
+}
+
+// TokenCounter defines the interface for counting tokens.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, model string, contents []*genai.Content, config *genai.CountTokensConfig) (*genai.CountTokensResponse, error)
+}
+
+// GenAITokenCounter is an adapter for genai.Client.Models
+type GenAITokenCounter struct {
+	Client *genai.Client
+}
+
+func (g *GenAITokenCounter) CountTokens(ctx context.Context, model string, contents []*genai.Content, config *genai.CountTokensConfig) (*genai.CountTokensResponse, error) {
+	return g.Client.Models.CountTokens(ctx, model, contents, config)
+}
+
+// BatchJobClient defines the interface for managing asynchronous batch jobs.
+type BatchJobClient interface {
+	Create(ctx context.Context, model string, src *genai.BatchJobSource, config *genai.CreateBatchJobConfig) (*genai.BatchJob, error)
+	Get(ctx context.Context, name string, config *genai.GetBatchJobConfig) (*genai.BatchJob, error)
+	Cancel(ctx context.Context, name string, config *genai.CancelBatchJobConfig) error
+}
+
+// GenAIBatchJobClient is an adapter for genai.Client.Batches
+type GenAIBatchJobClient struct {
+	Client *genai.Client
+}
+
+func (g *GenAIBatchJobClient) Create(ctx context.Context, model string, src *genai.BatchJobSource, config *genai.CreateBatchJobConfig) (*genai.BatchJob, error) {
+	return g.Client.Batches.Create(ctx, model, src, config)
+}
+
+func (g *GenAIBatchJobClient) Get(ctx context.Context, name string, config *genai.GetBatchJobConfig) (*genai.BatchJob, error) {
+	return g.Client.Batches.Get(ctx, name, config)
+}
+
+func (g *GenAIBatchJobClient) Cancel(ctx context.Context, name string, config *genai.CancelBatchJobConfig) error {
+	return g.Client.Batches.Cancel(ctx, name, config)
+}
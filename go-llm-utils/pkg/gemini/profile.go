@@ -0,0 +1,147 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultModel is used when neither the profile nor the caller names a model.
+const DefaultModel = "models/gemini-2.0-flash"
+
+// DefaultSystemInstructionFile is the system instruction of the default
+// profile, relative to the prompts directory.
+const DefaultSystemInstructionFile = "system/general-purpose.md"
+
+// Built-in profile names.
+const (
+	DefaultProfile           = "default"
+	PromptEngineeringProfile = "prompt-engineering"
+)
+
+// Profile is a named set of generation settings shared by the gemini
+// commands. Unset fields leave the API defaults in place. The system
+// instruction is the text of SystemInstructionFile, a prompt file relative to
+// the prompts directory, followed by SystemInstruction; the commands read the
+// file, GenerateContentConfig only uses SystemInstruction.
+type Profile struct {
+	Model                 string   `yaml:"model,omitempty" json:"model,omitempty"`
+	SystemInstructionFile string   `yaml:"system_instruction_file,omitempty" json:"system_instruction_file,omitempty"`
+	SystemInstruction     string   `yaml:"system_instruction,omitempty" json:"system_instruction,omitempty"`
+	CandidateCount        int32    `yaml:"candidate_count,omitempty" json:"candidate_count,omitempty"`
+	FrequencyPenalty      *float32 `yaml:"frequency_penalty,omitempty" json:"frequency_penalty,omitempty"`
+	MaxOutputTokens       int32    `yaml:"max_output_tokens,omitempty" json:"max_output_tokens,omitempty"`
+	PresencePenalty       *float32 `yaml:"presence_penalty,omitempty" json:"presence_penalty,omitempty"`
+	ResponseMIMEType      string   `yaml:"response_mime_type,omitempty" json:"response_mime_type,omitempty"`
+	Seed                  *int32   `yaml:"seed,omitempty" json:"seed,omitempty"`
+	Temperature           *float32 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	TopK                  *float32 `yaml:"top_k,omitempty" json:"top_k,omitempty"`
+	TopP                  *float32 `yaml:"top_p,omitempty" json:"top_p,omitempty"`
+	StopSequences         []string `yaml:"stop_sequences,omitempty" json:"stop_sequences,omitempty"`
+}
+
+// Profiles maps profile names to their settings.
+type Profiles map[string]Profile
+
+// DefaultProfiles returns the built-in profiles: default for everyday
+// generation and prompt-engineering for the meta-prompt driven commands.
+func DefaultProfiles() Profiles {
+	return Profiles{
+		DefaultProfile: {
+			Model:                 DefaultModel,
+			SystemInstructionFile: DefaultSystemInstructionFile,
+			SystemInstruction:     "Answer questions clearly, accurately, and provide additional context when relevant.",
+			CandidateCount:        1,
+			FrequencyPenalty:      F32(0),
+			MaxOutputTokens:       8192,
+			PresencePenalty:       F32(0),
+			ResponseMIMEType:      "text/plain",
+			Seed:                  I32(5),
+			StopSequences:         []string{"STOP!"},
+			Temperature:           F32(0.3),
+			TopK:                  F32(20),
+			TopP:                  F32(1),
+		},
+		PromptEngineeringProfile: {
+			Model:            "models/gemini-2.5-pro",
+			CandidateCount:   1,
+			MaxOutputTokens:  4096,
+			ResponseMIMEType: "text/plain",
+			Seed:             I32(12345),
+			Temperature:      F32(0.3),
+			TopP:             F32(1),
+		},
+	}
+}
+
+// LoadProfiles reads the profiles section of a project config file on top of
+// the built-in profiles. A profile in the file replaces the built-in profile
+// of the same name. A missing file yields the built-in profiles.
+func LoadProfiles(filename string) (Profiles, error) {
+	profiles := DefaultProfiles()
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Profiles Profiles `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles in %q: %w", filename, err)
+	}
+	for name, p := range config.Profiles {
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// Get returns the named profile.
+func (p Profiles) Get(name string) (Profile, error) {
+	profile, ok := p[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q (available: %v)", name, p.Names())
+	}
+	return profile, nil
+}
+
+// Names returns the profile names in sorted order.
+func (p Profiles) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GenerateContentConfig turns the profile into a request config. The model is
+// not part of the config and has to be passed to the call separately.
+func (p Profile) GenerateContentConfig() *genai.GenerateContentConfig {
+	config := &genai.GenerateContentConfig{
+		CandidateCount:   p.CandidateCount,
+		FrequencyPenalty: p.FrequencyPenalty,
+		MaxOutputTokens:  p.MaxOutputTokens,
+		PresencePenalty:  p.PresencePenalty,
+		ResponseMIMEType: p.ResponseMIMEType,
+		Seed:             p.Seed,
+		StopSequences:    p.StopSequences,
+		Temperature:      p.Temperature,
+		TopK:             p.TopK,
+		TopP:             p.TopP,
+	}
+	if p.SystemInstruction != "" {
+		config.SystemInstruction = genai.NewContentFromText(p.SystemInstruction, genai.RoleUser)
+	}
+	return config
+}
@@ -0,0 +1,92 @@
+package gemini
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func TestLoadProfilesMissingFile(t *testing.T) {
+	t.Parallel()
+
+	profiles, err := LoadProfiles(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfiles(), profiles)
+	assert.Equal(t, []string{DefaultProfile, PromptEngineeringProfile}, profiles.Names())
+
+	def := profiles[DefaultProfile]
+	assert.Equal(t, I32(5), def.Seed)
+	assert.Equal(t, []string{"STOP!"}, def.StopSequences)
+	assert.Equal(t, F32(0), def.FrequencyPenalty)
+	assert.Equal(t, F32(0), def.PresencePenalty)
+	assert.Equal(t, DefaultSystemInstructionFile, def.SystemInstructionFile)
+	assert.Contains(t, def.SystemInstruction, "Answer questions clearly")
+	assert.Equal(t, int32(4096), profiles[PromptEngineeringProfile].MaxOutputTokens)
+}
+
+func TestLoadProfilesOverlay(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), ".prompt-engineering.yaml")
+	config := "profiles:\n" +
+		"  default:\n" +
+		"    model: models/gemini-2.5-flash\n" +
+		"  creative:\n" +
+		"    model: models/gemini-2.5-pro\n" +
+		"    temperature: 1.2\n" +
+		"    seed: 7\n" +
+		"    stop_sequences: [\"END\"]\n"
+	require.NoError(t, os.WriteFile(filename, []byte(config), 0644))
+
+	profiles, err := LoadProfiles(filename)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"creative", DefaultProfile, PromptEngineeringProfile}, profiles.Names())
+
+	def, err := profiles.Get(DefaultProfile)
+	require.NoError(t, err)
+	assert.Equal(t, Profile{Model: "models/gemini-2.5-flash"}, def, "file profiles replace built-ins")
+
+	creative, err := profiles.Get("creative")
+	require.NoError(t, err)
+	assert.Equal(t, F32(1.2), creative.Temperature)
+	assert.Equal(t, I32(7), creative.Seed)
+	assert.Equal(t, []string{"END"}, creative.StopSequences)
+
+	_, err = profiles.Get("nope")
+	assert.EqualError(t, err, `unknown profile "nope" (available: [creative default prompt-engineering])`)
+}
+
+func TestLoadProfilesInvalid(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "bad.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("profiles: [1, 2]\n"), 0644))
+
+	_, err := LoadProfiles(filename)
+	assert.ErrorContains(t, err, "failed to parse profiles")
+}
+
+func TestProfileGenerateContentConfig(t *testing.T) {
+	t.Parallel()
+
+	p := Profile{
+		Model:             "models/gemini-2.0-flash",
+		SystemInstruction: "Be brief.",
+		MaxOutputTokens:   256,
+		Temperature:       F32(0.5),
+	}
+	config := p.GenerateContentConfig()
+
+	assert.Equal(t, int32(256), config.MaxOutputTokens)
+	assert.Equal(t, F32(0.5), config.Temperature)
+	assert.Nil(t, config.TopP)
+	require.NotNil(t, config.SystemInstruction)
+	assert.Equal(t, genai.RoleUser, config.SystemInstruction.Role)
+	assert.Equal(t, "Be brief.", config.SystemInstruction.Parts[0].Text)
+
+	assert.Nil(t, Profile{}.GenerateContentConfig().SystemInstruction)
+}
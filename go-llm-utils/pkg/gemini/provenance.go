@@ -0,0 +1,279 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// ProvenanceSuffix is appended to a response file name to name its
+// provenance sidecar: response.md has response.md.provenance.json.
+const ProvenanceSuffix = ".provenance.json"
+
+const sdkModule = "google.golang.org/genai"
+
+// Provenance records how a saved response was produced: the exact request,
+// the model that served it, the prompt files it came from and the SDK that
+// sent it, so the response can be explained and reproduced later. In memory
+// the file paths are absolute; the sidecar stores them relative to its own
+// directory, so it stays valid when read from elsewhere or moved along with
+// the response. Only WriteResponseWithProvenance writes a sidecar; the other
+// writers, such as WriteGeminiTextToMarkdown and WriteCandidatesToFiles, do
+// not, so their files cannot be reproduced.
+type Provenance struct {
+	Model        string                       `json:"model"`
+	ModelVersion string                       `json:"model_version,omitempty"`
+	ModelInfo    *ModelInfo                   `json:"model_info,omitempty"`
+	Config       *genai.GenerateContentConfig `json:"config,omitempty"`
+	Contents     []*genai.Content             `json:"contents"`
+	PromptFiles  []PromptFile                 `json:"prompt_files,omitempty"`
+	SDKVersion   string                       `json:"sdk_version,omitempty"`
+	Started      time.Time                    `json:"started"`
+	Finished     time.Time                    `json:"finished"`
+	ResponseID   string                       `json:"response_id,omitempty"`
+	Usage        *UsageReport                 `json:"usage,omitempty"`
+	Format       string                       `json:"format,omitempty"`
+	Output       string                       `json:"output,omitempty"`
+	OutputSHA256 string                       `json:"output_sha256,omitempty"`
+}
+
+// ModelInfo is what the models endpoint reported about the model.
+type ModelInfo struct {
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// PromptFile is a file a prompt was rendered from, with its hash at the
+// time of the request.
+type PromptFile struct {
+	Role   string `json:"role"`
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// ProvenanceFile names the sidecar of a response file.
+func ProvenanceFile(responseFile string) string {
+	return responseFile + ProvenanceSuffix
+}
+
+// NewProvenance starts a record for a request that is about to be sent.
+func NewProvenance(model string, contents []*genai.Content, config *genai.GenerateContentConfig) *Provenance {
+	return &Provenance{
+		Model:      model,
+		Contents:   contents,
+		Config:     config,
+		SDKVersion: SDKVersion(),
+		Started:    time.Now().UTC(),
+	}
+}
+
+// AddPromptFile records the hash of a file a prompt was rendered from.
+func (p *Provenance) AddPromptFile(role, path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	sum, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+	p.PromptFiles = append(p.PromptFiles, PromptFile{Role: role, Path: path, SHA256: sum})
+	return nil
+}
+
+// DescribeModel records the model name and version reported by the API.
+func (p *Provenance) DescribeModel(ctx context.Context, getter ModelGetter) error {
+	model, err := ModelsGet(ctx, getter, p.Model)
+	if err != nil {
+		return fmt.Errorf("failed to get model %q: %w", p.Model, err)
+	}
+	p.ModelInfo = &ModelInfo{Name: model.Name, Version: model.Version, DisplayName: model.DisplayName}
+	return nil
+}
+
+// RecordResponse records the parts of the response that identify it.
+func (p *Provenance) RecordResponse(resp *genai.GenerateContentResponse) {
+	p.Finished = time.Now().UTC()
+	if resp == nil {
+		return
+	}
+	p.ModelVersion = resp.ModelVersion
+	p.ResponseID = resp.ResponseID
+	p.Usage = NewResponseReport(resp).Usage
+}
+
+// Meta returns the writer metadata of the recorded request.
+func (p *Provenance) Meta() ResponseMeta {
+	meta := ResponseMeta{Model: p.Model, Config: p.Config, Created: p.Finished}
+	var prompt strings.Builder
+	for _, content := range p.Contents {
+		for _, part := range content.Parts {
+			prompt.WriteString(part.Text)
+		}
+	}
+	meta.Prompt = prompt.String()
+	return meta
+}
+
+// ChangedPromptFiles lists the recorded prompt files that are missing or no
+// longer match their hash. The recorded request does not depend on them,
+// but a changed file means a new run from the files would differ.
+func (p *Provenance) ChangedPromptFiles() []string {
+	var changed []string
+	for _, f := range p.PromptFiles {
+		if sum, err := HashFile(f.Path); err != nil || sum != f.SHA256 {
+			changed = append(changed, f.Path)
+		}
+	}
+	return changed
+}
+
+// Save writes the sidecar of the response file p.Output.
+func (p *Provenance) Save() (string, error) {
+	if p.Output == "" {
+		return "", fmt.Errorf("provenance has no output file")
+	}
+	output, err := filepath.Abs(p.Output)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(output)
+
+	saved := *p
+	saved.Output = relativeTo(dir, output)
+	saved.PromptFiles = make([]PromptFile, len(p.PromptFiles))
+	for i, f := range p.PromptFiles {
+		f.Path = relativeTo(dir, f.Path)
+		saved.PromptFiles[i] = f
+	}
+
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode provenance: %w", err)
+	}
+	return WriteFile(ProvenanceFile(output), append(data, '\n'), FileOptions{})
+}
+
+// relativeTo returns path relative to dir with forward slashes, or path
+// itself if it cannot be expressed that way.
+func relativeTo(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// resolveFrom turns a path stored in a sidecar in dir back into an absolute path.
+func resolveFrom(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, filepath.FromSlash(path))
+}
+
+// LoadProvenance reads a sidecar, given its own name or the name of the
+// response file it belongs to.
+func LoadProvenance(filename string) (*Provenance, error) {
+	if !strings.HasSuffix(filename, ProvenanceSuffix) {
+		filename = ProvenanceFile(filename)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var p Provenance
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse provenance %q: %w", filename, err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	p.Output = resolveFrom(dir, p.Output)
+	for i := range p.PromptFiles {
+		p.PromptFiles[i].Path = resolveFrom(dir, p.PromptFiles[i].Path)
+	}
+	return &p, nil
+}
+
+// WriteResponseWithProvenance writes the response like WriteResponseFile
+// and then the provenance sidecar next to the file written.
+func WriteResponseWithProvenance(filename string, rw ResponseWriter, resp *genai.GenerateContentResponse, p *Provenance, opts FileOptions) (string, error) {
+	if p.Finished.IsZero() {
+		p.RecordResponse(resp)
+	}
+	written, err := WriteResponseFile(filename, rw, resp, p.Meta(), opts)
+	if err != nil {
+		return "", err
+	}
+
+	if p.Output, err = filepath.Abs(written); err != nil {
+		return written, err
+	}
+	if p.OutputSHA256, err = HashFile(written); err != nil {
+		return written, err
+	}
+	if _, err := p.Save(); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// Reproduce sends the recorded request again.
+func Reproduce(ctx context.Context, gen ContentGenerator, p *Provenance) (*genai.GenerateContentResponse, error) {
+	if len(p.Contents) == 0 {
+		return nil, fmt.Errorf("provenance records no request contents")
+	}
+	resp, err := gen.GenerateContent(ctx, p.Model, p.Contents, p.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate content: %w", err)
+	}
+	return resp, nil
+}
+
+// HashFile returns the hex SHA-256 of a file.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SDKVersion returns the version of the genai module linked into the
+// binary, or the empty string if the build carries no module information.
+func SDKVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == sdkModule {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
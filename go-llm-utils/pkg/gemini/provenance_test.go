@@ -0,0 +1,130 @@
+package gemini
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+// recordingGenerator answers with a fixed text and remembers the last request.
+type recordingGenerator struct {
+	model    string
+	contents []*genai.Content
+	config   *genai.GenerateContentConfig
+}
+
+func (g *recordingGenerator) GenerateContent(_ context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	g.model, g.contents, g.config = model, contents, config
+	return &genai.GenerateContentResponse{
+		ModelVersion: "gemini-2.0-flash-001",
+		Candidates: []*genai.Candidate{{
+			Content:      genai.NewContentFromText("same answer", genai.RoleModel),
+			FinishReason: genai.FinishReasonStop,
+		}},
+	}, nil
+}
+
+type fixedModelGetter struct{}
+
+func (fixedModelGetter) Get(_ context.Context, name string, _ *genai.GetModelConfig) (*genai.Model, error) {
+	return &genai.Model{Name: name, Version: "001", DisplayName: "Gemini 2.0 Flash"}, nil
+}
+
+func TestProvenanceRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	promptFile := filepath.Join(dir, "prompt.md")
+	require.NoError(t, os.WriteFile(promptFile, []byte("Say something."), 0644))
+
+	profile := Profile{SystemInstruction: "Be brief.", Seed: I32(42), Temperature: F32(0)}
+	contents := []*genai.Content{genai.NewContentFromText("Say something.", genai.RoleUser)}
+	prov := NewProvenance("models/gemini-2.0-flash", contents, profile.GenerateContentConfig())
+	prov.Format = FormatMarkdown
+	require.NoError(t, prov.AddPromptFile("user", promptFile))
+	require.NoError(t, prov.DescribeModel(context.Background(), fixedModelGetter{}))
+
+	gen := &recordingGenerator{}
+	resp, err := gen.GenerateContent(context.Background(), prov.Model, prov.Contents, prov.Config)
+	require.NoError(t, err)
+
+	output := filepath.Join(dir, "response.md")
+	written, err := WriteResponseWithProvenance(output, &MarkdownWriter{}, resp, prov, FileOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, output, written)
+	assert.FileExists(t, output+ProvenanceSuffix)
+
+	loaded, err := LoadProvenance(output)
+	require.NoError(t, err)
+	assert.Equal(t, "models/gemini-2.0-flash", loaded.Model)
+	assert.Equal(t, "gemini-2.0-flash-001", loaded.ModelVersion)
+	assert.Equal(t, &ModelInfo{Name: "models/gemini-2.0-flash", Version: "001", DisplayName: "Gemini 2.0 Flash"}, loaded.ModelInfo)
+	assert.Equal(t, I32(42), loaded.Config.Seed)
+	assert.Equal(t, "Be brief.", loaded.Config.SystemInstruction.Parts[0].Text)
+	assert.Equal(t, output, loaded.Output)
+	assert.Equal(t, hashText("same answer"), loaded.OutputSHA256)
+	assert.Equal(t, []PromptFile{{Role: "user", Path: promptFile, SHA256: hashText("Say something.")}}, loaded.PromptFiles)
+	assert.False(t, loaded.Started.IsZero())
+	assert.False(t, loaded.Finished.Before(loaded.Started))
+	assert.Empty(t, loaded.ChangedPromptFiles())
+
+	// Reproducing sends exactly the recorded request.
+	replay := &recordingGenerator{}
+	_, err = Reproduce(context.Background(), replay, loaded)
+	require.NoError(t, err)
+	assert.Equal(t, "models/gemini-2.0-flash", replay.model)
+	assert.Equal(t, "Say something.", replay.contents[0].Parts[0].Text)
+	assert.Equal(t, I32(42), replay.config.Seed)
+	assert.Equal(t, F32(0), replay.config.Temperature)
+
+	require.NoError(t, os.WriteFile(promptFile, []byte("Say something else."), 0644))
+	assert.Equal(t, []string{promptFile}, loaded.ChangedPromptFiles())
+
+	sidecar, err := LoadProvenance(output + ProvenanceSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, loaded, sidecar, "the sidecar can be named directly")
+}
+
+func TestProvenanceRelocated(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "run")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "prompts"), 0755))
+	promptFile := filepath.Join(dir, "prompts", "prompt.md")
+	require.NoError(t, os.WriteFile(promptFile, []byte("Say something."), 0644))
+
+	contents := []*genai.Content{genai.NewContentFromText("Say something.", genai.RoleUser)}
+	prov := NewProvenance("models/gemini-2.0-flash", contents, nil)
+	require.NoError(t, prov.AddPromptFile("user", promptFile))
+	resp, err := (&recordingGenerator{}).GenerateContent(context.Background(), prov.Model, contents, nil)
+	require.NoError(t, err)
+	output := filepath.Join(dir, "out", "response.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(output), 0755))
+	_, err = WriteResponseWithProvenance(output, &MarkdownWriter{}, resp, prov, FileOptions{})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(output + ProvenanceSuffix)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"output": "response.md"`)
+	assert.Contains(t, string(data), `"path": "../prompts/prompt.md"`)
+
+	moved := filepath.Join(filepath.Dir(dir), "moved")
+	require.NoError(t, os.Rename(dir, moved))
+	loaded, err := LoadProvenance(filepath.Join(moved, "out", "response.md"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(moved, "out", "response.md"), loaded.Output)
+	assert.Equal(t, filepath.Join(moved, "prompts", "prompt.md"), loaded.PromptFiles[0].Path)
+	assert.Empty(t, loaded.ChangedPromptFiles(), "paths resolve against the moved sidecar")
+}
+
+func TestReproduceWithoutContents(t *testing.T) {
+	t.Parallel()
+
+	_, err := Reproduce(context.Background(), &recordingGenerator{}, &Provenance{Model: "models/x"})
+	assert.EqualError(t, err, "provenance records no request contents")
+}
@@ -0,0 +1,253 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// ResponseReport collects what a response says about itself besides its
+// text: why generation stopped, how the safety filters rated the prompt and
+// the candidates, what was cited and how many tokens were used.
+type ResponseReport struct {
+	ModelVersion   string            `json:"model_version,omitempty"`
+	ResponseID     string            `json:"response_id,omitempty"`
+	PromptFeedback *PromptFeedback   `json:"prompt_feedback,omitempty"`
+	Candidates     []CandidateReport `json:"candidates,omitempty"`
+	Usage          *UsageReport      `json:"usage,omitempty"`
+	// Error explains why the response carries no text, as in CheckResponse.
+	Error string `json:"error,omitempty"`
+}
+
+// PromptFeedback reports whether and why the prompt was blocked.
+type PromptFeedback struct {
+	BlockReason   string         `json:"block_reason,omitempty"`
+	BlockMessage  string         `json:"block_message,omitempty"`
+	SafetyRatings []SafetyRating `json:"safety_ratings,omitempty"`
+}
+
+// CandidateReport describes one response candidate.
+type CandidateReport struct {
+	Index         int            `json:"index"`
+	Text          string         `json:"text,omitempty"`
+	FinishReason  string         `json:"finish_reason,omitempty"`
+	FinishMessage string         `json:"finish_message,omitempty"`
+	TokenCount    int32          `json:"token_count,omitempty"`
+	AvgLogprobs   float64        `json:"avg_logprobs,omitempty"`
+	SafetyRatings []SafetyRating `json:"safety_ratings,omitempty"`
+	Citations     []Citation     `json:"citations,omitempty"`
+}
+
+// SafetyRating is the harm probability the safety filters assigned to one category.
+type SafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability,omitempty"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// Citation is a source the candidate quotes from; StartIndex and EndIndex
+// delimit the quoted part of the text.
+type Citation struct {
+	Title      string `json:"title,omitempty"`
+	URI        string `json:"uri,omitempty"`
+	License    string `json:"license,omitempty"`
+	StartIndex int32  `json:"start_index,omitempty"`
+	EndIndex   int32  `json:"end_index,omitempty"`
+}
+
+// UsageReport counts the tokens of a request.
+type UsageReport struct {
+	PromptTokens   int32 `json:"prompt_tokens" yaml:"prompt_tokens"`
+	CachedTokens   int32 `json:"cached_tokens,omitempty" yaml:"cached_tokens,omitempty"`
+	ToolUseTokens  int32 `json:"tool_use_tokens,omitempty" yaml:"tool_use_tokens,omitempty"`
+	ResponseTokens int32 `json:"response_tokens" yaml:"response_tokens"`
+	ThoughtsTokens int32 `json:"thoughts_tokens,omitempty" yaml:"thoughts_tokens,omitempty"`
+	TotalTokens    int32 `json:"total_tokens" yaml:"total_tokens"`
+}
+
+// NewResponseReport builds the report of resp; a nil response gives a
+// report with only an error.
+func NewResponseReport(resp *genai.GenerateContentResponse) *ResponseReport {
+	report := &ResponseReport{}
+	if err := CheckResponse(resp); err != nil {
+		report.Error = err.Error()
+	}
+	if resp == nil {
+		return report
+	}
+
+	report.ModelVersion = resp.ModelVersion
+	report.ResponseID = resp.ResponseID
+
+	if fb := resp.PromptFeedback; fb != nil {
+		feedback := &PromptFeedback{
+			BlockMessage:  fb.BlockReasonMessage,
+			SafetyRatings: safetyRatings(fb.SafetyRatings),
+		}
+		if fb.BlockReason != genai.BlockedReasonUnspecified {
+			feedback.BlockReason = string(fb.BlockReason)
+		}
+		if feedback.BlockReason != "" || feedback.BlockMessage != "" || len(feedback.SafetyRatings) > 0 {
+			report.PromptFeedback = feedback
+		}
+	}
+
+	for i, cand := range resp.Candidates {
+		c := CandidateReport{
+			Index:         i,
+			Text:          candidateText(cand),
+			FinishReason:  string(cand.FinishReason),
+			FinishMessage: cand.FinishMessage,
+			TokenCount:    cand.TokenCount,
+			AvgLogprobs:   cand.AvgLogprobs,
+			SafetyRatings: safetyRatings(cand.SafetyRatings),
+		}
+		if cand.CitationMetadata != nil {
+			for _, citation := range cand.CitationMetadata.Citations {
+				c.Citations = append(c.Citations, Citation{
+					Title:      citation.Title,
+					URI:        citation.URI,
+					License:    citation.License,
+					StartIndex: citation.StartIndex,
+					EndIndex:   citation.EndIndex,
+				})
+			}
+		}
+		report.Candidates = append(report.Candidates, c)
+	}
+
+	if usage := resp.UsageMetadata; usage != nil {
+		report.Usage = &UsageReport{
+			PromptTokens:   usage.PromptTokenCount,
+			CachedTokens:   usage.CachedContentTokenCount,
+			ToolUseTokens:  usage.ToolUsePromptTokenCount,
+			ResponseTokens: usage.CandidatesTokenCount,
+			ThoughtsTokens: usage.ThoughtsTokenCount,
+			TotalTokens:    usage.TotalTokenCount,
+		}
+	}
+	return report
+}
+
+func safetyRatings(ratings []*genai.SafetyRating) []SafetyRating {
+	var out []SafetyRating
+	for _, r := range ratings {
+		out = append(out, SafetyRating{
+			Category:    string(r.Category),
+			Probability: string(r.Probability),
+			Blocked:     r.Blocked,
+		})
+	}
+	return out
+}
+
+// candidateText concatenates the text parts of a candidate, skipping thoughts.
+func candidateText(cand *genai.Candidate) string {
+	if cand == nil || cand.Content == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range cand.Content.Parts {
+		if isAnswer(part) {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+// isAnswer reports whether part is answer text rather than a thought
+// summary, which models include when thinking is configured to.
+func isAnswer(part *genai.Part) bool {
+	return part != nil && !part.Thought && part.Text != ""
+}
+
+// Print writes the report in a readable form, without the candidate texts.
+func (r *ResponseReport) Print(w io.Writer) error {
+	var b strings.Builder
+
+	if r.ModelVersion != "" || r.ResponseID != "" {
+		fmt.Fprintf(&b, "model: %s", r.ModelVersion)
+		if r.ResponseID != "" {
+			fmt.Fprintf(&b, " (response %s)", r.ResponseID)
+		}
+		b.WriteString("\n")
+	}
+
+	if fb := r.PromptFeedback; fb != nil {
+		if fb.BlockReason != "" {
+			fmt.Fprintf(&b, "prompt: blocked for %s", fb.BlockReason)
+			if fb.BlockMessage != "" {
+				fmt.Fprintf(&b, ": %s", fb.BlockMessage)
+			}
+			b.WriteString("\n")
+		}
+		writeSafetyRatings(&b, "prompt safety", fb.SafetyRatings)
+	}
+
+	for _, c := range r.Candidates {
+		fmt.Fprintf(&b, "candidate %d: finish reason %s, %d characters", c.Index, orNone(c.FinishReason), len(c.Text))
+		if c.TokenCount > 0 {
+			fmt.Fprintf(&b, ", %d tokens", c.TokenCount)
+		}
+		if c.AvgLogprobs != 0 {
+			fmt.Fprintf(&b, ", avg logprob %.4f", c.AvgLogprobs)
+		}
+		b.WriteString("\n")
+		if c.FinishMessage != "" {
+			fmt.Fprintf(&b, "  finish message: %s\n", c.FinishMessage)
+		}
+		writeSafetyRatings(&b, "  safety", c.SafetyRatings)
+		for _, citation := range c.Citations {
+			fmt.Fprintf(&b, "  citation [%d:%d]", citation.StartIndex, citation.EndIndex)
+			for _, field := range []string{citation.Title, citation.URI, citation.License} {
+				if field != "" {
+					fmt.Fprintf(&b, " %s", field)
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if u := r.Usage; u != nil {
+		fmt.Fprintf(&b, "tokens: %d prompt", u.PromptTokens)
+		if u.CachedTokens > 0 {
+			fmt.Fprintf(&b, " (%d cached)", u.CachedTokens)
+		}
+		if u.ToolUseTokens > 0 {
+			fmt.Fprintf(&b, ", %d tool use", u.ToolUseTokens)
+		}
+		fmt.Fprintf(&b, ", %d response, %d thoughts, %d total\n", u.ResponseTokens, u.ThoughtsTokens, u.TotalTokens)
+	}
+
+	if r.Error != "" {
+		fmt.Fprintf(&b, "error: %s\n", r.Error)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSafetyRatings(b *strings.Builder, label string, ratings []SafetyRating) {
+	if len(ratings) == 0 {
+		return
+	}
+	var parts []string
+	for _, r := range ratings {
+		part := fmt.Sprintf("%s %s", strings.TrimPrefix(r.Category, "HARM_CATEGORY_"), r.Probability)
+		if r.Blocked {
+			part += " (blocked)"
+		}
+		parts = append(parts, part)
+	}
+	fmt.Fprintf(b, "%s: %s\n", label, strings.Join(parts, ", "))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
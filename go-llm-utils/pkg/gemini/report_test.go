@@ -0,0 +1,95 @@
+package gemini
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func TestNewResponseReport(t *testing.T) {
+	t.Parallel()
+
+	resp := &genai.GenerateContentResponse{
+		ModelVersion: "gemini-2.0-flash-001",
+		ResponseID:   "abc",
+		Candidates: []*genai.Candidate{{
+			Content: &genai.Content{Parts: []*genai.Part{
+				{Text: "thinking", Thought: true},
+				{Text: "Hello "},
+				{Text: "world"},
+			}},
+			FinishReason: genai.FinishReasonStop,
+			TokenCount:   2,
+			AvgLogprobs:  -0.25,
+			SafetyRatings: []*genai.SafetyRating{
+				{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityNegligible},
+			},
+			CitationMetadata: &genai.CitationMetadata{Citations: []*genai.Citation{
+				{Title: "Greetings", URI: "https://example.com", StartIndex: 0, EndIndex: 5},
+			}},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:        10,
+			CachedContentTokenCount: 4,
+			CandidatesTokenCount:    2,
+			ThoughtsTokenCount:      7,
+			TotalTokenCount:         19,
+		},
+	}
+
+	report := NewResponseReport(resp)
+	assert.Empty(t, report.Error)
+	assert.Nil(t, report.PromptFeedback)
+	require.Len(t, report.Candidates, 1)
+	assert.Equal(t, CandidateReport{
+		Text:          "Hello world",
+		FinishReason:  "STOP",
+		TokenCount:    2,
+		AvgLogprobs:   -0.25,
+		SafetyRatings: []SafetyRating{{Category: "HARM_CATEGORY_HARASSMENT", Probability: "NEGLIGIBLE"}},
+		Citations:     []Citation{{Title: "Greetings", URI: "https://example.com", EndIndex: 5}},
+	}, report.Candidates[0])
+	assert.Equal(t, &UsageReport{PromptTokens: 10, CachedTokens: 4, ResponseTokens: 2, ThoughtsTokens: 7, TotalTokens: 19}, report.Usage)
+
+	var out strings.Builder
+	require.NoError(t, report.Print(&out))
+	assert.Equal(t, `model: gemini-2.0-flash-001 (response abc)
+candidate 0: finish reason STOP, 11 characters, 2 tokens, avg logprob -0.2500
+  safety: HARASSMENT NEGLIGIBLE
+  citation [0:5] Greetings https://example.com
+tokens: 10 prompt (4 cached), 2 response, 7 thoughts, 19 total
+`, out.String())
+}
+
+func TestNewResponseReportBlockedPrompt(t *testing.T) {
+	t.Parallel()
+
+	report := NewResponseReport(&genai.GenerateContentResponse{
+		PromptFeedback: &genai.GenerateContentResponsePromptFeedback{
+			BlockReason: genai.BlockedReasonSafety,
+			SafetyRatings: []*genai.SafetyRating{
+				{Category: genai.HarmCategoryDangerousContent, Probability: genai.HarmProbabilityMedium, Blocked: true},
+			},
+		},
+	})
+	require.NotNil(t, report.PromptFeedback)
+	assert.Equal(t, "SAFETY", report.PromptFeedback.BlockReason)
+	assert.Equal(t, "response blocked: prompt blocked for SAFETY (DANGEROUS_CONTENT: MEDIUM)", report.Error)
+
+	var out strings.Builder
+	require.NoError(t, report.Print(&out))
+	assert.Equal(t, `prompt: blocked for SAFETY
+prompt safety: DANGEROUS_CONTENT MEDIUM (blocked)
+error: response blocked: prompt blocked for SAFETY (DANGEROUS_CONTENT: MEDIUM)
+`, out.String())
+}
+
+func TestNewResponseReportNil(t *testing.T) {
+	t.Parallel()
+
+	report := NewResponseReport(nil)
+	assert.Equal(t, &ResponseReport{Error: "empty response: no response from model"}, report)
+}
@@ -0,0 +1,105 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+var (
+	// ErrBlocked is returned when the prompt or the response was blocked,
+	// for example by the safety filters.
+	ErrBlocked = errors.New("response blocked")
+	// ErrEmptyResponse is returned when the model produced no text.
+	ErrEmptyResponse = errors.New("empty response")
+)
+
+// blockedFinishReasons are the finish reasons that mean the candidate was
+// withheld rather than completed.
+var blockedFinishReasons = map[genai.FinishReason]bool{
+	genai.FinishReasonSafety:            true,
+	genai.FinishReasonRecitation:        true,
+	genai.FinishReasonBlocklist:         true,
+	genai.FinishReasonProhibitedContent: true,
+	genai.FinishReasonSPII:              true,
+	genai.FinishReasonImageSafety:       true,
+}
+
+// finishReasonHints explain the finish reasons that leave a response without text.
+var finishReasonHints = map[genai.FinishReason]string{
+	genai.FinishReasonMaxTokens:             "the output token limit was reached before any text was produced; raise max_output_tokens",
+	genai.FinishReasonSafety:                "the safety filters flagged the response",
+	genai.FinishReasonRecitation:            "the response repeated its training data too closely",
+	genai.FinishReasonBlocklist:             "the response contained blocklisted terms",
+	genai.FinishReasonProhibitedContent:     "the response may contain prohibited content",
+	genai.FinishReasonSPII:                  "the response may contain sensitive personal information",
+	genai.FinishReasonImageSafety:           "the safety filters flagged a generated image",
+	genai.FinishReasonMalformedFunctionCall: "the model produced an invalid function call",
+}
+
+// CheckResponse reports whether the first candidate of resp carries text.
+// The error wraps ErrBlocked or ErrEmptyResponse so callers can tell the
+// cases apart with errors.Is.
+func CheckResponse(resp *genai.GenerateContentResponse) error {
+	if resp == nil {
+		return fmt.Errorf("%w: no response from model", ErrEmptyResponse)
+	}
+
+	if fb := resp.PromptFeedback; fb != nil && fb.BlockReason != "" && fb.BlockReason != genai.BlockedReasonUnspecified {
+		details := blockedCategories(fb.SafetyRatings)
+		if fb.BlockReasonMessage != "" {
+			return fmt.Errorf("%w: prompt blocked for %s%s: %s", ErrBlocked, fb.BlockReason, details, fb.BlockReasonMessage)
+		}
+		return fmt.Errorf("%w: prompt blocked for %s%s", ErrBlocked, fb.BlockReason, details)
+	}
+
+	if len(resp.Candidates) == 0 {
+		return fmt.Errorf("%w: model returned no candidates", ErrEmptyResponse)
+	}
+
+	candidate := resp.Candidates[0]
+	if blockedFinishReasons[candidate.FinishReason] {
+		return fmt.Errorf("%w: response stopped for %s%s: %s", ErrBlocked, candidate.FinishReason,
+			blockedCategories(candidate.SafetyRatings), explain(candidate))
+	}
+
+	if candidateText(candidate) != "" {
+		return nil
+	}
+	if candidate.FinishReason == "" {
+		return fmt.Errorf("%w: no text in response", ErrEmptyResponse)
+	}
+	err := fmt.Errorf("%w: no text in response (finish reason %s)", ErrEmptyResponse, candidate.FinishReason)
+	if hint := explain(candidate); hint != "" {
+		err = fmt.Errorf("%w: %s", err, hint)
+	}
+	if candidate.FinishReason == genai.FinishReasonMaxTokens && resp.UsageMetadata != nil && resp.UsageMetadata.ThoughtsTokenCount > 0 {
+		err = fmt.Errorf("%w (%d tokens went to thinking)", err, resp.UsageMetadata.ThoughtsTokenCount)
+	}
+	return err
+}
+
+// explain describes why a candidate stopped, preferring the message from the API.
+func explain(candidate *genai.Candidate) string {
+	if candidate.FinishMessage != "" {
+		return candidate.FinishMessage
+	}
+	return finishReasonHints[candidate.FinishReason]
+}
+
+// blockedCategories lists the harm categories that caused a block, as " (HARASSMENT: HIGH)".
+func blockedCategories(ratings []*genai.SafetyRating) string {
+	var blocked []string
+	for _, r := range ratings {
+		if r.Blocked {
+			blocked = append(blocked, fmt.Sprintf("%s: %s", strings.TrimPrefix(string(r.Category), "HARM_CATEGORY_"), r.Probability))
+		}
+	}
+	if len(blocked) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(blocked, ", ") + ")"
+}
@@ -0,0 +1,140 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genai"
+)
+
+func TestCheckResponse(t *testing.T) {
+	t.Parallel()
+
+	text := func(s string, reason genai.FinishReason) *genai.GenerateContentResponse {
+		return &genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{{
+				Content:      genai.NewContentFromText(s, genai.RoleModel),
+				FinishReason: reason,
+			}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		resp    *genai.GenerateContentResponse
+		target  error
+		message string
+	}{
+		{
+			name: "text",
+			resp: text("hello", genai.FinishReasonStop),
+		},
+		{
+			name:    "nil",
+			target:  ErrEmptyResponse,
+			message: "empty response: no response from model",
+		},
+		{
+			name: "prompt blocked",
+			resp: &genai.GenerateContentResponse{
+				PromptFeedback: &genai.GenerateContentResponsePromptFeedback{
+					BlockReason:        genai.BlockedReasonSafety,
+					BlockReasonMessage: "harassment",
+				},
+			},
+			target:  ErrBlocked,
+			message: "response blocked: prompt blocked for SAFETY: harassment",
+		},
+		{
+			name:    "no candidates",
+			resp:    &genai.GenerateContentResponse{},
+			target:  ErrEmptyResponse,
+			message: "empty response: model returned no candidates",
+		},
+		{
+			name:    "candidate blocked",
+			resp:    &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonRecitation}}},
+			target:  ErrBlocked,
+			message: "response blocked: response stopped for RECITATION: the response repeated its training data too closely",
+		},
+		{
+			name: "candidate blocked by safety",
+			resp: &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+				FinishReason: genai.FinishReasonSafety,
+				SafetyRatings: []*genai.SafetyRating{
+					{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityHigh, Blocked: true},
+					{Category: genai.HarmCategoryHateSpeech, Probability: genai.HarmProbabilityNegligible},
+				},
+			}}},
+			target:  ErrBlocked,
+			message: "response blocked: response stopped for SAFETY (HARASSMENT: HIGH): the safety filters flagged the response",
+		},
+		{
+			name: "finish message wins over the hint",
+			resp: &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+				FinishReason:  genai.FinishReasonProhibitedContent,
+				FinishMessage: "Response was blocked.",
+			}}},
+			target:  ErrBlocked,
+			message: "response blocked: response stopped for PROHIBITED_CONTENT: Response was blocked.",
+		},
+		{
+			name:    "token limit before any text",
+			resp:    text("", genai.FinishReasonMaxTokens),
+			target:  ErrEmptyResponse,
+			message: "empty response: no text in response (finish reason MAX_TOKENS): the output token limit was reached before any text was produced; raise max_output_tokens",
+		},
+		{
+			name: "token limit spent on thinking",
+			resp: &genai.GenerateContentResponse{
+				Candidates:    []*genai.Candidate{{FinishReason: genai.FinishReasonMaxTokens}},
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{ThoughtsTokenCount: 1024},
+			},
+			target:  ErrEmptyResponse,
+			message: "empty response: no text in response (finish reason MAX_TOKENS): the output token limit was reached before any text was produced; raise max_output_tokens (1024 tokens went to thinking)",
+		},
+		{
+			name: "only thoughts",
+			resp: &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{
+					Content:      &genai.Content{Parts: []*genai.Part{{Text: "Let me think.", Thought: true}}},
+					FinishReason: genai.FinishReasonMaxTokens,
+				}},
+			},
+			target:  ErrEmptyResponse,
+			message: "empty response: no text in response (finish reason MAX_TOKENS): the output token limit was reached before any text was produced; raise max_output_tokens",
+		},
+		{
+			name:    "no content",
+			resp:    &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{}}},
+			target:  ErrEmptyResponse,
+			message: "empty response: no text in response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckResponse(tt.resp)
+			if tt.target == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.target)
+			assert.EqualError(t, err, tt.message)
+		})
+	}
+}
+
+func TestResponseText(t *testing.T) {
+	t.Parallel()
+
+	resp := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content: &genai.Content{Parts: []*genai.Part{{Text: "The user wants a greeting.", Thought: true}, {Text: "Hello, "}, {}, nil, {Text: "world"}}},
+		}},
+	}
+	text, err := ResponseText(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, world", text)
+}
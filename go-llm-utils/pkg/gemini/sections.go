@@ -0,0 +1,126 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// Section tags used by the meta-prompts in prompts/system.
+const (
+	ReasoningTag   = "reasoning"
+	AnswerTag      = "answer"
+	FinalAnswerTag = "final_answer"
+)
+
+// ParsedResponse is model output split into its tagged sections.
+type ParsedResponse struct {
+	// Raw is the unmodified response text.
+	Raw string
+	// Sections maps each tag found to its trimmed contents. Repeated tags are
+	// joined with a blank line.
+	Sections map[string]string
+	// Answer is the content of a requested answer or final_answer section, or
+	// otherwise all text outside the extracted sections.
+	Answer string
+	// Incomplete lists tags that were opened but never closed, which usually
+	// means the output hit the token limit.
+	Incomplete []string
+}
+
+// Reasoning returns the leading <reasoning> section, if any.
+func (p *ParsedResponse) Reasoning() string {
+	return p.Sections[ReasoningTag]
+}
+
+// leadingReasoning matches a <reasoning> block at the very start of a response.
+var leadingReasoning = regexp.MustCompile(`(?is)\A\s*<` + ReasoningTag + `\s*>(.*?)(</` + ReasoningTag + `\s*>|\z)`)
+
+// ParseSections splits model output into its tagged sections. A <reasoning>
+// block is only split off when the response starts with it, so tags that
+// are part of the answer, such as a generated prompt asking for reasoning,
+// are left alone. tags names the sections to extract from the rest of the
+// text; include AnswerTag or FinalAnswerTag to take the answer from those
+// sections instead of the remaining text. Tag matching is case-insensitive.
+func ParseSections(text string, tags ...string) *ParsedResponse {
+	parsed := &ParsedResponse{Raw: text, Sections: map[string]string{}}
+
+	rest := text
+	if m := leadingReasoning.FindStringSubmatchIndex(text); m != nil {
+		parsed.Sections[ReasoningTag] = strings.TrimSpace(text[m[2]:m[3]])
+		if m[4] == m[5] {
+			parsed.Incomplete = append(parsed.Incomplete, ReasoningTag)
+		}
+		rest = text[m[1]:]
+	}
+
+	var requested []string
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if !slices.Contains(requested, tag) {
+			requested = append(requested, tag)
+		}
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	var answers []string
+
+	for _, tag := range requested {
+		re := regexp.MustCompile(`(?is)<` + regexp.QuoteMeta(tag) + `\s*>(.*?)(</` + regexp.QuoteMeta(tag) + `\s*>|\z)`)
+		var contents []string
+		for _, m := range re.FindAllStringSubmatchIndex(rest, -1) {
+			spans = append(spans, span{m[0], m[1]})
+			contents = append(contents, strings.TrimSpace(rest[m[2]:m[3]]))
+			if m[4] == m[5] && !slices.Contains(parsed.Incomplete, tag) {
+				parsed.Incomplete = append(parsed.Incomplete, tag)
+			}
+		}
+		if len(contents) == 0 {
+			continue
+		}
+
+		joined := strings.Join(contents, "\n\n")
+		switch {
+		case tag == AnswerTag || tag == FinalAnswerTag:
+			answers = append(answers, joined)
+		case parsed.Sections[tag] != "":
+			parsed.Sections[tag] += "\n\n" + joined
+		default:
+			parsed.Sections[tag] = joined
+		}
+	}
+
+	if len(answers) > 0 {
+		parsed.Answer = strings.Join(answers, "\n\n")
+		return parsed
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var answer strings.Builder
+	pos := 0
+	for _, s := range spans {
+		if s.start < pos {
+			continue // nested inside a section already removed
+		}
+		answer.WriteString(rest[pos:s.start])
+		pos = s.end
+	}
+	answer.WriteString(rest[pos:])
+	parsed.Answer = strings.TrimSpace(answer.String())
+
+	return parsed
+}
+
+// ParseResponse extracts the text of the first candidate and splits it into sections.
+func ParseResponse(resp *genai.GenerateContentResponse, tags ...string) (*ParsedResponse, error) {
+	text, err := ResponseText(resp)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSections(text, tags...), nil
+}
@@ -0,0 +1,102 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func TestParseSections(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		text       string
+		tags       []string
+		sections   map[string]string
+		answer     string
+		incomplete []string
+	}{
+		{
+			name:     "no tags",
+			text:     "  Just the prompt.\n",
+			sections: map[string]string{},
+			answer:   "Just the prompt.",
+		},
+		{
+			name:     "leading reasoning",
+			text:     "<reasoning>\n- Simple Change: no\n</reasoning>\n\nYou are an expert.\n\n# Output Format\n",
+			sections: map[string]string{"reasoning": "- Simple Change: no"},
+			answer:   "You are an expert.\n\n# Output Format",
+		},
+		{
+			name:     "requested answer wins over remaining text",
+			text:     "<REASONING>why</REASONING> noise <final_answer>42</final_answer> trailer",
+			tags:     []string{FinalAnswerTag},
+			sections: map[string]string{"reasoning": "why"},
+			answer:   "42",
+		},
+		{
+			name:     "repeated and custom tags",
+			text:     "<reasoning>a</reasoning><notes>n</notes>body<notes>m</notes>",
+			tags:     []string{"Notes"},
+			sections: map[string]string{"reasoning": "a", "notes": "n\n\nm"},
+			answer:   "body",
+		},
+		{
+			name:     "reasoning later in the text is part of the answer",
+			text:     "You are a tutor.\n\nThink in <reasoning></reasoning> tags first.",
+			sections: map[string]string{},
+			answer:   "You are a tutor.\n\nThink in <reasoning></reasoning> tags first.",
+		},
+		{
+			name:     "answer tags are kept unless requested",
+			text:     "<reasoning>r</reasoning>\nWrap the result in <answer></answer> or <final_answer>x</final_answer>.",
+			sections: map[string]string{"reasoning": "r"},
+			answer:   "Wrap the result in <answer></answer> or <final_answer>x</final_answer>.",
+		},
+		{
+			name:       "unterminated reasoning",
+			text:       "<reasoning>\nthe model ran out of tokens",
+			sections:   map[string]string{"reasoning": "the model ran out of tokens"},
+			answer:     "",
+			incomplete: []string{"reasoning"},
+		},
+		{
+			name:     "unknown tags stay in the answer",
+			text:     "<reasoning>r</reasoning>Use <b>bold</b> text.",
+			sections: map[string]string{"reasoning": "r"},
+			answer:   "Use <b>bold</b> text.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := ParseSections(tt.text, tt.tags...)
+			assert.Equal(t, tt.text, got.Raw)
+			assert.Equal(t, tt.sections, got.Sections)
+			assert.Equal(t, tt.answer, got.Answer)
+			assert.Equal(t, tt.incomplete, got.Incomplete)
+		})
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	t.Parallel()
+
+	resp := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content: genai.NewContentFromText("<reasoning>think</reasoning>\nFinal prompt", genai.RoleModel),
+		}},
+	}
+	parsed, err := ParseResponse(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "think", parsed.Reasoning())
+	assert.Equal(t, "Final prompt", parsed.Answer)
+
+	_, err = ParseResponse(&genai.GenerateContentResponse{})
+	assert.ErrorIs(t, err, ErrEmptyResponse)
+}
@@ -5,18 +5,23 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/prompts"
 	"google.golang.org/genai"
 )
 
 func F32(v float32) *float32 { return &v }
 func I32(v int32) *int32     { return &v }
+func F64(v float64) *float64 { return &v }
 
 func PrintResponse(resp *genai.GenerateContentResponse) {
 	for _, cand := range resp.Candidates {
 		if cand.Content != nil {
 			for _, part := range cand.Content.Parts {
-				fmt.Println(part.Text)
+				if isAnswer(part) {
+					fmt.Println(part.Text)
+				}
 			}
 		}
 	}
@@ -30,33 +35,52 @@ func ReadTextFromFile(filename string) (string, error) {
 	return string(data), nil
 }
 
-func WriteGeminiTextToMarkdown(resp *genai.GenerateContentResponse, outputPath string) error {
-	if resp == nil || len(resp.Candidates) == 0 {
-		return fmt.Errorf("invalid or empty response from model")
+// ReadPromptFromFile reads a prompt file with the same semantics as
+// read_prompt_from_file in python-llm-utils; see prompts.Clean.
+func ReadPromptFromFile(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
 	}
-
-	candidate := resp.Candidates[0]
-	if candidate.Content == nil || len(candidate.Content.Parts) == 0 {
-		return fmt.Errorf("response candidate has no content")
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("prompt file %q is not valid UTF-8", filename)
 	}
+	return prompts.Clean(string(data)), nil
+}
 
-	var rawText string
-	for _, part := range candidate.Content.Parts {
-		if part.Text != "" {
-			rawText += part.Text
-		}
+// ResponseText returns the concatenated text parts of the first candidate.
+// Blocked and empty responses are reported as in CheckResponse.
+func ResponseText(resp *genai.GenerateContentResponse) (string, error) {
+	if err := CheckResponse(resp); err != nil {
+		return "", err
 	}
 
-	if rawText == "" {
-		return fmt.Errorf("no text found in response candidate parts")
+	return candidateText(resp.Candidates[0]), nil
+}
+
+func WriteGeminiTextToMarkdown(resp *genai.GenerateContentResponse, outputPath string) error {
+	rawText, err := ResponseText(resp)
+	if err != nil {
+		return err
 	}
+	return WriteTextToMarkdown(rawText, outputPath)
+}
+
+// WriteTextToMarkdown writes model text to a markdown file, for callers that
+// post-process the response before persisting it. The file is replaced
+// atomically, see WriteFile.
+func WriteTextToMarkdown(text, outputPath string) error {
+	_, err := writeMarkdown(text, outputPath, FileOptions{})
+	return err
+}
 
-	formattedText := strings.ReplaceAll(rawText, "\\n", "\n")
+// writeMarkdown is WriteTextToMarkdown with file options; it returns the file written.
+func writeMarkdown(text, outputPath string, opts FileOptions) (string, error) {
+	formattedText := strings.ReplaceAll(text, "\\n", "\n")
 
-	err := os.WriteFile(outputPath, []byte(formattedText), 0644)
+	written, err := WriteFile(outputPath, []byte(formattedText), opts)
 	if err != nil {
-		return fmt.Errorf("failed to write markdown file %q: %w", outputPath, err)
+		return "", fmt.Errorf("failed to write markdown file %q: %w", outputPath, err)
 	}
-
-	return nil
+	return written, nil
 }
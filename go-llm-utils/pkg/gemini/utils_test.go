@@ -0,0 +1,60 @@
+package gemini
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readPromptCase is a fixture shared with the Python read_prompt_from_file tests.
+type readPromptCase struct {
+	Name     string `json:"name"`
+	Input    string `json:"input"`
+	Expected string `json:"expected"`
+}
+
+func TestReadPromptFromFile(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "testdata", "read-prompt-from-file", "cases.json"))
+	require.NoError(t, err)
+
+	var cases []readPromptCase
+	require.NoError(t, json.Unmarshal(data, &cases))
+	require.NotEmpty(t, cases)
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			filename := filepath.Join(t.TempDir(), "prompt.md")
+			require.NoError(t, os.WriteFile(filename, []byte(tc.Input), 0644))
+
+			got, err := ReadPromptFromFile(filename)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, got)
+		})
+	}
+}
+
+func TestReadPromptFromFileErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+		_, err := ReadPromptFromFile(filepath.Join(t.TempDir(), "missing.md"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("invalid UTF-8", func(t *testing.T) {
+		t.Parallel()
+		filename := filepath.Join(t.TempDir(), "prompt.md")
+		require.NoError(t, os.WriteFile(filename, []byte{0xff, 0xfe, 'h', 'i'}, 0644))
+
+		_, err := ReadPromptFromFile(filename)
+		assert.ErrorContains(t, err, "is not valid UTF-8")
+	})
+}
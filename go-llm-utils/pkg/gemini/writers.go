@@ -0,0 +1,341 @@
+//revive:disable:package-comments,exported
+package gemini
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+// ResponseMeta describes the request that produced a response, for the
+// writers that record it next to the text.
+type ResponseMeta struct {
+	Model        string
+	Config       *genai.GenerateContentConfig
+	SystemPrompt string
+	Prompt       string
+	// Created defaults to the time the response is written.
+	Created time.Time
+}
+
+// ResponseWriter renders a response to w.
+type ResponseWriter interface {
+	WriteResponse(w io.Writer, resp *genai.GenerateContentResponse, meta ResponseMeta) error
+}
+
+// Output formats accepted by NewResponseWriter.
+const (
+	FormatMarkdown    = "markdown"
+	FormatFrontMatter = "frontmatter"
+	FormatJSON        = "json"
+	FormatHTML        = "html"
+)
+
+// Formats lists the output formats accepted by NewResponseWriter.
+func Formats() []string {
+	return []string{FormatMarkdown, FormatFrontMatter, FormatJSON, FormatHTML}
+}
+
+// NewResponseWriter returns the writer for a format; selection chooses the
+// candidate for the formats that write a single one.
+func NewResponseWriter(format string, selection CandidateScorer) (ResponseWriter, error) {
+	switch format {
+	case FormatMarkdown:
+		return &MarkdownWriter{Select: selection}, nil
+	case FormatFrontMatter:
+		return &FrontMatterWriter{Select: selection}, nil
+	case FormatJSON:
+		return &JSONWriter{}, nil
+	case FormatHTML:
+		return &HTMLWriter{}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (available: %s)", format, strings.Join(Formats(), ", "))
+}
+
+// FormatForFile guesses the output format from the file extension and
+// falls back to markdown.
+func FormatForFile(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".html", ".htm":
+		return FormatHTML
+	}
+	return FormatMarkdown
+}
+
+// WriteResponseFile renders resp with rw into filename, see WriteFile. It
+// returns the file written.
+func WriteResponseFile(filename string, rw ResponseWriter, resp *genai.GenerateContentResponse, meta ResponseMeta, opts FileOptions) (string, error) {
+	var buf bytes.Buffer
+	if err := rw.WriteResponse(&buf, resp, meta); err != nil {
+		return "", err
+	}
+	return WriteFile(filename, buf.Bytes(), opts)
+}
+
+// MarkdownWriter writes the text of the selected candidate as is.
+type MarkdownWriter struct {
+	// Select chooses the candidate; it defaults to SelectFirst.
+	Select CandidateScorer
+	// UnescapeNewlines turns literal \n sequences into line breaks, for
+	// models that escape the newlines of their answer.
+	UnescapeNewlines bool
+}
+
+func (m *MarkdownWriter) WriteResponse(w io.Writer, resp *genai.GenerateContentResponse, _ ResponseMeta) error {
+	_, text, err := SelectCandidate(resp, m.Select)
+	if err != nil {
+		return err
+	}
+	if m.UnescapeNewlines {
+		text = strings.ReplaceAll(text, "\\n", "\n")
+	}
+	_, err = io.WriteString(w, text)
+	return err
+}
+
+// FrontMatter is the YAML header written by FrontMatterWriter. Prompts are
+// recorded as SHA-256 hashes so the file does not repeat them.
+type FrontMatter struct {
+	Model        string       `yaml:"model,omitempty" json:"model,omitempty"`
+	ModelVersion string       `yaml:"model_version,omitempty" json:"model_version,omitempty"`
+	Created      time.Time    `yaml:"created" json:"created"`
+	Config       *Profile     `yaml:"config,omitempty" json:"config,omitempty"`
+	SystemSHA256 string       `yaml:"system_sha256,omitempty" json:"system_sha256,omitempty"`
+	PromptSHA256 string       `yaml:"prompt_sha256,omitempty" json:"prompt_sha256,omitempty"`
+	FinishReason string       `yaml:"finish_reason,omitempty" json:"finish_reason,omitempty"`
+	Usage        *UsageReport `yaml:"usage,omitempty" json:"usage,omitempty"`
+}
+
+// NewFrontMatter collects the metadata of a response; candidate selects the
+// candidate whose finish reason is recorded.
+func NewFrontMatter(resp *genai.GenerateContentResponse, meta ResponseMeta, candidate int) FrontMatter {
+	fm := FrontMatter{
+		Model:        meta.Model,
+		Created:      meta.Created,
+		SystemSHA256: hashText(meta.SystemPrompt),
+		PromptSHA256: hashText(meta.Prompt),
+	}
+	if fm.Created.IsZero() {
+		fm.Created = time.Now()
+	}
+	fm.Created = fm.Created.UTC().Truncate(time.Second)
+
+	if meta.Config != nil {
+		config := ProfileFromConfig(meta.Config)
+		if fm.SystemSHA256 == "" && config.SystemInstruction != "" {
+			fm.SystemSHA256 = hashText(config.SystemInstruction)
+		}
+		config.SystemInstruction = ""
+		fm.Config = &config
+	}
+
+	if resp != nil {
+		fm.ModelVersion = resp.ModelVersion
+		if candidate >= 0 && candidate < len(resp.Candidates) {
+			fm.FinishReason = string(resp.Candidates[candidate].FinishReason)
+		}
+		fm.Usage = NewResponseReport(resp).Usage
+	}
+	return fm
+}
+
+// ProfileFromConfig is the inverse of Profile.GenerateContentConfig; settings
+// a profile cannot express are dropped.
+func ProfileFromConfig(config *genai.GenerateContentConfig) Profile {
+	p := Profile{
+		CandidateCount:   config.CandidateCount,
+		FrequencyPenalty: config.FrequencyPenalty,
+		MaxOutputTokens:  config.MaxOutputTokens,
+		PresencePenalty:  config.PresencePenalty,
+		ResponseMIMEType: config.ResponseMIMEType,
+		Seed:             config.Seed,
+		StopSequences:    config.StopSequences,
+		Temperature:      config.Temperature,
+		TopK:             config.TopK,
+		TopP:             config.TopP,
+	}
+	if config.SystemInstruction != nil {
+		var text strings.Builder
+		for _, part := range config.SystemInstruction.Parts {
+			text.WriteString(part.Text)
+		}
+		p.SystemInstruction = text.String()
+	}
+	return p
+}
+
+// hashText returns the hex SHA-256 of text, or the empty string for no text.
+func hashText(text string) string {
+	if text == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// FrontMatterWriter writes the selected candidate as markdown preceded by a
+// YAML front matter block, see FrontMatter.
+type FrontMatterWriter struct {
+	// Select chooses the candidate; it defaults to SelectFirst.
+	Select CandidateScorer
+}
+
+func (f *FrontMatterWriter) WriteResponse(w io.Writer, resp *genai.GenerateContentResponse, meta ResponseMeta) error {
+	index, text, err := SelectCandidate(resp, f.Select)
+	if err != nil {
+		return err
+	}
+	header, err := yaml.Marshal(NewFrontMatter(resp, meta, index))
+	if err != nil {
+		return fmt.Errorf("failed to encode front matter: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n\n")
+	buf.WriteString(text)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// JSONWriter dumps the complete response, every candidate included, with
+// the front matter metadata.
+type JSONWriter struct{}
+
+// ResponseRecord is the document written by JSONWriter.
+type ResponseRecord struct {
+	Metadata FrontMatter                    `json:"metadata"`
+	Response *genai.GenerateContentResponse `json:"response"`
+}
+
+func (j *JSONWriter) WriteResponse(w io.Writer, resp *genai.GenerateContentResponse, meta ResponseMeta) error {
+	if resp == nil {
+		return fmt.Errorf("%w: no response from model", ErrEmptyResponse)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ResponseRecord{Metadata: NewFrontMatter(resp, meta, 0), Response: resp})
+}
+
+// HTMLWriter renders every candidate and the response metadata as a single
+// HTML page without external resources.
+type HTMLWriter struct {
+	// Title defaults to the model name.
+	Title string
+}
+
+func (h *HTMLWriter) WriteResponse(w io.Writer, resp *genai.GenerateContentResponse, meta ResponseMeta) error {
+	if err := checkCandidates(resp); err != nil {
+		return err
+	}
+
+	fm := NewFrontMatter(resp, meta, 0)
+	title := h.Title
+	if title == "" {
+		title = "Response from " + fm.Model
+	}
+
+	report := NewResponseReport(resp)
+	var details strings.Builder
+	if err := report.Print(&details); err != nil {
+		return err
+	}
+
+	config := map[string]any{}
+	if fm.Config != nil {
+		data, err := yaml.Marshal(fm.Config)
+		if err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+	}
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var settings []htmlSetting
+	for _, key := range keys {
+		settings = append(settings, htmlSetting{key, fmt.Sprint(config[key])})
+	}
+
+	return htmlTemplate.Execute(w, htmlPage{
+		Title:      title,
+		Meta:       fm,
+		Settings:   settings,
+		Candidates: report.Candidates,
+		Details:    details.String(),
+	})
+}
+
+type htmlSetting struct{ Name, Value string }
+
+type htmlPage struct {
+	Title      string
+	Meta       FrontMatter
+	Settings   []htmlSetting
+	Candidates []CandidateReport
+	Details    string
+}
+
+var htmlTemplate = template.Must(template.New("response").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 50rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #222; }
+table { border-collapse: collapse; font-size: 0.9rem; }
+th, td { text-align: left; padding: 0.2rem 1rem 0.2rem 0; vertical-align: top; }
+th { color: #666; font-weight: normal; }
+.text { white-space: pre-wrap; border-left: 3px solid #ddd; padding-left: 1rem; }
+.empty { color: #a00; font-style: italic; }
+pre { background: #f6f6f6; padding: 0.5rem; overflow-x: auto; font-size: 0.85rem; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<table>
+{{- with .Meta.Model }}
+<tr><th>model</th><td>{{ . }}</td></tr>{{ end }}
+{{- with .Meta.ModelVersion }}
+<tr><th>model version</th><td>{{ . }}</td></tr>{{ end }}
+<tr><th>created</th><td>{{ .Meta.Created.Format "2006-01-02 15:04:05 UTC" }}</td></tr>
+{{- range .Settings }}
+<tr><th>{{ .Name }}</th><td>{{ .Value }}</td></tr>{{ end }}
+{{- with .Meta.SystemSHA256 }}
+<tr><th>system sha256</th><td><code>{{ . }}</code></td></tr>{{ end }}
+{{- with .Meta.PromptSHA256 }}
+<tr><th>prompt sha256</th><td><code>{{ . }}</code></td></tr>{{ end }}
+</table>
+{{ range .Candidates }}
+<section>
+<h2>Candidate {{ inc .Index }}{{ with .FinishReason }} <small>({{ . }})</small>{{ end }}</h2>
+{{ if .Text }}<div class="text">{{ .Text }}</div>{{ else }}<p class="empty">no text</p>{{ end }}
+</section>
+{{ end }}
+<details>
+<summary>Response details</summary>
+<pre>{{ .Details }}</pre>
+</details>
+</body>
+</html>
+`))
@@ -0,0 +1,133 @@
+package gemini
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+func writerFixture() (*genai.GenerateContentResponse, ResponseMeta) {
+	resp := &genai.GenerateContentResponse{
+		ModelVersion: "gemini-2.0-flash-001",
+		Candidates: []*genai.Candidate{{
+			Content:      genai.NewContentFromText("# Title\\n\nBody <b>bold</b>", genai.RoleModel),
+			FinishReason: genai.FinishReasonStop,
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount: 4, CandidatesTokenCount: 6, TotalTokenCount: 10,
+		},
+	}
+	profile := Profile{SystemInstruction: "Be brief.", Temperature: F32(0.5), Seed: I32(7)}
+	meta := ResponseMeta{
+		Model:   "models/gemini-2.0-flash",
+		Config:  profile.GenerateContentConfig(),
+		Prompt:  "Write a title.",
+		Created: time.Date(2025, 7, 1, 12, 30, 0, 0, time.UTC),
+	}
+	return resp, meta
+}
+
+func render(t *testing.T, rw ResponseWriter) string {
+	t.Helper()
+	resp, meta := writerFixture()
+	var out strings.Builder
+	require.NoError(t, rw.WriteResponse(&out, resp, meta))
+	return out.String()
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "# Title\\n\nBody <b>bold</b>", render(t, &MarkdownWriter{}))
+	assert.Equal(t, "# Title\n\nBody <b>bold</b>", render(t, &MarkdownWriter{UnescapeNewlines: true}))
+}
+
+func TestFrontMatterWriter(t *testing.T) {
+	t.Parallel()
+
+	out := render(t, &FrontMatterWriter{})
+	require.True(t, strings.HasPrefix(out, "---\n"))
+	header, body, ok := strings.Cut(strings.TrimPrefix(out, "---\n"), "---\n\n")
+	require.True(t, ok)
+	assert.Equal(t, "# Title\\n\nBody <b>bold</b>", body)
+
+	var fm FrontMatter
+	require.NoError(t, yaml.Unmarshal([]byte(header), &fm))
+	assert.Equal(t, "models/gemini-2.0-flash", fm.Model)
+	assert.Equal(t, "gemini-2.0-flash-001", fm.ModelVersion)
+	assert.Equal(t, time.Date(2025, 7, 1, 12, 30, 0, 0, time.UTC), fm.Created)
+	assert.Equal(t, "STOP", fm.FinishReason)
+	assert.Equal(t, hashText("Be brief."), fm.SystemSHA256, "system prompt hashed from the config")
+	assert.Equal(t, hashText("Write a title."), fm.PromptSHA256)
+	assert.Len(t, fm.PromptSHA256, 64)
+	require.NotNil(t, fm.Config)
+	assert.Equal(t, Profile{Temperature: F32(0.5), Seed: I32(7)}, *fm.Config, "system instruction is not repeated")
+	assert.Equal(t, &UsageReport{PromptTokens: 4, ResponseTokens: 6, TotalTokens: 10}, fm.Usage)
+	assert.NotContains(t, header, "Be brief.")
+}
+
+func TestJSONWriter(t *testing.T) {
+	t.Parallel()
+
+	var record ResponseRecord
+	require.NoError(t, json.Unmarshal([]byte(render(t, &JSONWriter{})), &record))
+	assert.Equal(t, "models/gemini-2.0-flash", record.Metadata.Model)
+	require.NotNil(t, record.Response)
+	text, err := ResponseText(record.Response)
+	require.NoError(t, err)
+	assert.Equal(t, "# Title\\n\nBody <b>bold</b>", text)
+}
+
+func TestHTMLWriter(t *testing.T) {
+	t.Parallel()
+
+	out := render(t, &HTMLWriter{})
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.Contains(t, out, "<title>Response from models/gemini-2.0-flash</title>")
+	assert.Contains(t, out, "Body &lt;b&gt;bold&lt;/b&gt;", "response text is escaped")
+	assert.Contains(t, out, "<h2>Candidate 1 <small>(STOP)</small></h2>")
+	assert.Contains(t, out, "<tr><th>temperature</th><td>0.5</td></tr>")
+	assert.NotContains(t, out, "<link")
+	assert.NotContains(t, out, "<script")
+}
+
+func TestWriteResponseFile(t *testing.T) {
+	t.Parallel()
+
+	resp, meta := writerFixture()
+	filename := filepath.Join(t.TempDir(), "response.md")
+	written, err := WriteResponseFile(filename, &MarkdownWriter{}, resp, meta, FileOptions{Perm: 0600})
+	require.NoError(t, err)
+	assert.Equal(t, filename, written)
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, err = WriteResponseFile(filename, &MarkdownWriter{}, &genai.GenerateContentResponse{}, meta, FileOptions{})
+	assert.ErrorIs(t, err, ErrEmptyResponse)
+}
+
+func TestNewResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range Formats() {
+		rw, err := NewResponseWriter(format, SelectLongest)
+		require.NoError(t, err, format)
+		assert.NotNil(t, rw)
+	}
+	_, err := NewResponseWriter("pdf", nil)
+	assert.EqualError(t, err, "unknown output format \"pdf\" (available: markdown, frontmatter, json, html)")
+
+	assert.Equal(t, FormatJSON, FormatForFile("out/response.JSON"))
+	assert.Equal(t, FormatHTML, FormatForFile("response.html"))
+	assert.Equal(t, FormatMarkdown, FormatForFile("response.md"))
+}
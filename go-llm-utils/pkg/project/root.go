@@ -0,0 +1,75 @@
+//revive:disable:package-comments,exported
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// RootEnv overrides root discovery with an explicit project root.
+	RootEnv = "PROMPT_ENGINEERING_ROOT"
+	// ConfigFile marks a project root explicitly.
+	ConfigFile = ".prompt-engineering.yaml"
+)
+
+// markerDir identifies the project root by its prompt library. A bare
+// "prompts" directory is not enough: go-llm-utils/pkg/prompts would match.
+var markerDir = filepath.Join("prompts", "system")
+
+// FindRoot returns the project root: the directory named by RootEnv if set,
+// otherwise the nearest ancestor of the working directory that contains
+// ConfigFile or prompts/system.
+func FindRoot() (string, error) {
+	if root, ok := os.LookupEnv(RootEnv); ok && root != "" {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return "", fmt.Errorf("error resolving %s: %w", RootEnv, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return "", fmt.Errorf("%s points to an invalid directory: %w", RootEnv, err)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("%s points to %q, which is not a directory", RootEnv, abs)
+		}
+		return abs, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("error getting working directory: %w", err)
+	}
+	return FindRootFrom(wd)
+}
+
+// FindRootFrom walks up from dir looking for ConfigFile or prompts/system.
+func FindRootFrom(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for start := dir; ; {
+		if isFile(filepath.Join(dir, ConfigFile)) || isDir(filepath.Join(dir, markerDir)) {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("project root not found from %q: no %s or %s in any parent directory (set %s to override)",
+				start, ConfigFile, filepath.ToSlash(markerDir), RootEnv)
+		}
+		dir = parent
+	}
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
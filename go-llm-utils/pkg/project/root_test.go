@@ -0,0 +1,84 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindRootFrom(t *testing.T) {
+	t.Parallel()
+
+	t.Run("prompt library marker", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "prompts", "system"), 0755))
+		// A nested pkg/prompts directory must not be mistaken for the root.
+		nested := filepath.Join(root, "go-llm-utils", "pkg", "prompts")
+		require.NoError(t, os.MkdirAll(nested, 0755))
+
+		got, err := FindRootFrom(nested)
+		require.NoError(t, err)
+		assert.Equal(t, root, got)
+	})
+
+	t.Run("config file marker", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(root, ConfigFile), nil, 0644))
+		sub := filepath.Join(root, "a", "b")
+		require.NoError(t, os.MkdirAll(sub, 0755))
+
+		got, err := FindRootFrom(sub)
+		require.NoError(t, err)
+		assert.Equal(t, root, got)
+	})
+
+	t.Run("repository root", func(t *testing.T) {
+		t.Parallel()
+		want, err := filepath.Abs(filepath.Join("..", "..", ".."))
+		require.NoError(t, err)
+
+		got, err := FindRootFrom(".")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		_, err := FindRootFrom(t.TempDir())
+		assert.ErrorContains(t, err, "project root not found")
+	})
+}
+
+// TestFindRootEnv manipulates the environment and cannot run in parallel.
+func TestFindRootEnv(t *testing.T) {
+	t.Run("override", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv(RootEnv, dir)
+
+		got, err := FindRoot()
+		require.NoError(t, err)
+		assert.Equal(t, dir, got)
+	})
+
+	t.Run("override must be a directory", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(file, nil, 0644))
+		t.Setenv(RootEnv, file)
+
+		_, err := FindRoot()
+		assert.ErrorContains(t, err, "is not a directory")
+	})
+
+	t.Run("empty override falls back to discovery", func(t *testing.T) {
+		t.Setenv(RootEnv, "")
+
+		got, err := FindRoot()
+		require.NoError(t, err)
+		assert.DirExists(t, filepath.Join(got, "prompts", "system"))
+	})
+}
@@ -0,0 +1,34 @@
+//revive:disable:package-comments,exported
+package prompts
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Clean prepares prompt text the way read_prompt_from_file does in
+// python-llm-utils: line endings are normalized, leading blank lines are
+// removed, a leading markdown header line is dropped and the remaining text is
+// trimmed of surrounding whitespace.
+func Clean(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.SplitAfter(text, "\n")
+
+	for len(lines) > 0 && trimPythonSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && strings.HasPrefix(trimPythonSpace(lines[0]), "#") {
+		lines = lines[1:]
+	}
+
+	return trimPythonSpace(strings.Join(lines, ""))
+}
+
+// trimPythonSpace trims the characters Python's str.strip removes, which are
+// the Unicode spaces plus the ASCII file, group, record and unit separators.
+func trimPythonSpace(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || (r >= 0x1c && r <= 0x1f)
+	})
+}
@@ -0,0 +1,94 @@
+//revive:disable:package-comments,exported
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+// Parameter describes a variable a prompt expects to be filled in.
+type Parameter struct {
+	Name        string `yaml:"name" json:"name"`
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Default     any    `yaml:"default,omitempty" json:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
+// Metadata is the optional YAML front matter at the top of a prompt file.
+type Metadata struct {
+	ID          string      `yaml:"id,omitempty" json:"id,omitempty"`
+	Version     string      `yaml:"version,omitempty" json:"version,omitempty"`
+	TargetModel string      `yaml:"target_model,omitempty" json:"target_model,omitempty"`
+	Tags        []string    `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Author      string      `yaml:"author,omitempty" json:"author,omitempty"`
+	Parameters  []Parameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	// Template marks a prompt without parameters, such as one that only
+	// includes partials, as a template.
+	Template bool `yaml:"template,omitempty" json:"template,omitempty"`
+}
+
+// IsTemplate reports whether the front matter opts the prompt in to templating.
+func (m Metadata) IsTemplate() bool {
+	return m.Template || len(m.Parameters) > 0
+}
+
+// ParseFrontMatter splits data into its YAML front matter and the markdown body.
+// Front matter is only recognized when the very first line is "---"; it ends at
+// the next "---" line. Files without front matter return zero Metadata, the
+// unchanged content and found == false.
+func ParseFrontMatter(data []byte) (meta Metadata, body string, found bool, err error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	first, rest, ok := cutLine(data)
+	if !ok || string(bytes.TrimRight(first, " \t\r")) != frontMatterDelimiter {
+		return Metadata{}, string(data), false, nil
+	}
+
+	var header []byte
+	for {
+		var line []byte
+		line, rest, ok = cutLine(rest)
+		if string(bytes.TrimRight(line, " \t\r")) == frontMatterDelimiter {
+			break
+		}
+		if !ok {
+			return Metadata{}, "", false, fmt.Errorf("front matter is not terminated by %q", frontMatterDelimiter)
+		}
+		header = append(header, line...)
+		header = append(header, '\n')
+	}
+
+	if err := yaml.Unmarshal(header, &meta); err != nil {
+		return Metadata{}, "", false, fmt.Errorf("failed to parse front matter: %w", err)
+	}
+	for i, p := range meta.Parameters {
+		if p.Name == "" {
+			return Metadata{}, "", false, fmt.Errorf("front matter parameter %d has no name", i)
+		}
+	}
+
+	return meta, string(rest), true, nil
+}
+
+// cutLine returns the first line of data without its newline and the remainder.
+// ok is false when data contains no newline, in which case line is all of data.
+func cutLine(data []byte) (line, rest []byte, ok bool) {
+	line, rest, ok = bytes.Cut(data, []byte("\n"))
+	return line, rest, ok
+}
+
+// FormatFrontMatter prepends meta as YAML front matter to body.
+func FormatFrontMatter(meta Metadata, body string) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(meta); err != nil {
+		return "", fmt.Errorf("failed to encode front matter: %w", err)
+	}
+	return frontMatterDelimiter + "\n" + buf.String() + frontMatterDelimiter + "\n" + body, nil
+}
@@ -0,0 +1,100 @@
+package prompts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("full metadata", func(t *testing.T) {
+		t.Parallel()
+		data := []byte(`---
+id: mql5-assistant
+version: "1.2"
+target_model: models/gemini-2.5-pro
+tags: [mql5, trading]
+author: jane
+parameters:
+  - name: language
+    type: string
+    default: MQL5
+  - name: max_examples
+    type: int
+    required: true
+---
+# MQL5 Assistant
+
+You are an expert.
+`)
+		meta, body, found, err := ParseFrontMatter(data)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, Metadata{
+			ID:          "mql5-assistant",
+			Version:     "1.2",
+			TargetModel: "models/gemini-2.5-pro",
+			Tags:        []string{"mql5", "trading"},
+			Author:      "jane",
+			Parameters: []Parameter{
+				{Name: "language", Type: "string", Default: "MQL5"},
+				{Name: "max_examples", Type: "int", Required: true},
+			},
+		}, meta)
+		assert.Equal(t, "# MQL5 Assistant\n\nYou are an expert.\n", body)
+	})
+
+	t.Run("no front matter", func(t *testing.T) {
+		t.Parallel()
+		meta, body, found, err := ParseFrontMatter([]byte("# Title\n---\nid: x\n"))
+		require.NoError(t, err)
+		assert.False(t, found)
+		assert.Zero(t, meta)
+		assert.Equal(t, "# Title\n---\nid: x\n", body)
+	})
+
+	t.Run("CRLF line endings", func(t *testing.T) {
+		t.Parallel()
+		meta, body, found, err := ParseFrontMatter([]byte("---\r\nid: x\r\n---\r\nbody"))
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "x", meta.ID)
+		assert.Equal(t, "body", body)
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		t.Parallel()
+		_, _, _, err := ParseFrontMatter([]byte("---\nid: x\nbody\n"))
+		assert.EqualError(t, err, `front matter is not terminated by "---"`)
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		t.Parallel()
+		_, _, _, err := ParseFrontMatter([]byte("---\ntags: [a\n---\n"))
+		assert.ErrorContains(t, err, "failed to parse front matter")
+	})
+
+	t.Run("unnamed parameter", func(t *testing.T) {
+		t.Parallel()
+		_, _, _, err := ParseFrontMatter([]byte("---\nparameters:\n  - type: string\n---\n"))
+		assert.EqualError(t, err, "front matter parameter 0 has no name")
+	})
+}
+
+func TestFormatFrontMatter(t *testing.T) {
+	t.Parallel()
+	meta := Metadata{ID: "x", Version: "v2", Tags: []string{"a"}}
+
+	text, err := FormatFrontMatter(meta, "body\n")
+	require.NoError(t, err)
+	assert.Equal(t, "---\nid: x\nversion: v2\ntags:\n  - a\n---\nbody\n", text)
+
+	parsed, body, found, err := ParseFrontMatter([]byte(text))
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, meta, parsed)
+	assert.Equal(t, "body\n", body)
+}
@@ -0,0 +1,233 @@
+//revive:disable:package-comments,exported
+package prompts
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	versionName = regexp.MustCompile(`^v\d+(\.\d+)*$`)
+	modelName   = regexp.MustCompile(`^(gemini|gpt|claude|o\d)`)
+)
+
+// Prompt is a single prompt file of the library.
+type Prompt struct {
+	Metadata
+	// Path is the file location relative to the library root, using forward slashes.
+	Path string
+	// Body is the prompt text with any front matter removed.
+	Body string
+	// HasFrontMatter reports whether the metadata came from the file rather than its location.
+	HasFrontMatter bool
+}
+
+// LoadPrompt reads a single prompt file. Metadata missing from the front matter
+// is inferred from rel, the file's path relative to the library root.
+func LoadPrompt(filename, rel string) (*Prompt, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, body, found, err := ParseFrontMatter(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rel, err)
+	}
+
+	p := &Prompt{Metadata: meta, Path: filepath.ToSlash(rel), Body: body, HasFrontMatter: found}
+	p.inferMetadata()
+	return p, nil
+}
+
+// inferMetadata fills in the ID, version and target model from the directory
+// layout when the front matter does not set them. A file named like a version
+// ("v4.md") is a version of the prompt identified by its directory, and one
+// with a version suffix ("advisor-v2.md", as NextVersionFile names them) is a
+// version of the prompt named without it. Other files in a directory with a
+// version suffix ("assistant-v3/gemini-pro2.5.md") are that version of the
+// prompt named after the directory without the suffix and the file. A file
+// named like a model ("gemini-pro2.5.md") targets that model.
+func (p *Prompt) inferMetadata() {
+	dir, file := path.Split(strings.TrimSuffix(p.Path, path.Ext(p.Path)))
+	dir = cleanSegments(dir)
+	file = strings.TrimSpace(file)
+
+	version := ""
+	if m := versionSuffix.FindStringSubmatch(dir); m != nil {
+		dir = strings.TrimSuffix(dir, m[0])
+		version = "v" + m[1]
+	}
+	id := path.Join(dir, file)
+	switch {
+	case versionName.MatchString(file) && dir != "":
+		id, version = dir, file
+	case versionSuffix.MatchString(file):
+		version = FileVersion(file)
+		file = strings.TrimSuffix(file, "-"+version)
+		id = path.Join(dir, file)
+	}
+	if p.Version == "" {
+		p.Version = version
+	}
+	if p.ID == "" {
+		p.ID = id
+	}
+	if p.TargetModel == "" && modelName.MatchString(file) {
+		p.TargetModel = file
+	}
+}
+
+func cleanSegments(dir string) string {
+	segments := strings.Split(strings.Trim(dir, "/"), "/")
+	for i, s := range segments {
+		segments[i] = strings.TrimSpace(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Library is an index of the prompt files under a directory.
+type Library struct {
+	Root     string
+	prompts  []*Prompt
+	byID     map[string][]*Prompt
+	problems []error
+}
+
+// LoadLibrary indexes every markdown file under root. A file that repeats
+// the ID and version of one already indexed is left out and reported by
+// Problems rather than failing the whole library.
+func LoadLibrary(root string) (*Library, error) {
+	lib := &Library{Root: root, byID: map[string][]*Prompt{}}
+
+	err := filepath.WalkDir(root, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(filename), ".md") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filename)
+		if err != nil {
+			return err
+		}
+
+		p, err := LoadPrompt(filename, rel)
+		if err != nil {
+			return err
+		}
+
+		for _, other := range lib.byID[p.ID] {
+			if other.Version == p.Version {
+				lib.problems = append(lib.problems, fmt.Errorf("duplicate prompt %s version %q in %s and %s", p.ID, p.Version, other.Path, p.Path))
+				return nil
+			}
+		}
+
+		lib.prompts = append(lib.prompts, p)
+		lib.byID[p.ID] = append(lib.byID[p.ID], p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt library %q: %w", root, err)
+	}
+
+	for _, versions := range lib.byID {
+		sort.Slice(versions, func(i, j int) bool {
+			return CompareVersions(versions[i].Version, versions[j].Version) < 0
+		})
+	}
+
+	return lib, nil
+}
+
+// Problems returns the files LoadLibrary left out of the index and why.
+func (l *Library) Problems() []error {
+	return append([]error(nil), l.problems...)
+}
+
+// All returns every prompt of the library ordered by path.
+func (l *Library) All() []*Prompt {
+	all := append([]*Prompt(nil), l.prompts...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Path < all[j].Path })
+	return all
+}
+
+// IDs returns the sorted prompt IDs of the library.
+func (l *Library) IDs() []string {
+	ids := make([]string, 0, len(l.byID))
+	for id := range l.byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Versions returns every version of the prompt with the given ID, oldest first.
+func (l *Library) Versions(id string) []*Prompt {
+	return append([]*Prompt(nil), l.byID[id]...)
+}
+
+// Get returns the prompt with the given ID and version. An empty version
+// selects the latest one.
+func (l *Library) Get(id, version string) (*Prompt, error) {
+	versions := l.byID[id]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("prompt %q not found", id)
+	}
+	if version == "" {
+		return versions[len(versions)-1], nil
+	}
+	for _, p := range versions {
+		if p.Version == version {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt %q has no version %q", id, version)
+}
+
+// CompareVersions orders version strings such as "v2", "1.10" and "1.2.3"
+// numerically component by component; the leading "v" is optional. Versions
+// that are not numeric fall back to string comparison. It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var sa, sb string
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		if sa == "" {
+			na, errA = 0, nil
+		}
+		if sb == "" {
+			nb, errB = 0, nil
+		}
+
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case (errA != nil || errB != nil) && sa != sb:
+			return strings.Compare(sa, sb)
+		}
+	}
+	return 0
+}
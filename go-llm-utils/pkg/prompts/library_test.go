@@ -0,0 +1,161 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePrompt(t *testing.T, root, rel, content string) {
+	t.Helper()
+	filename := filepath.Join(root, rel)
+	require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+}
+
+func TestLoadLibrary(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	writePrompt(t, root, "task-specific/mql5/v2.md", "second")
+	writePrompt(t, root, "task-specific/mql5/v10.md", "tenth")
+	writePrompt(t, root, "task-specific/mql5/v3.md", "third")
+	writePrompt(t, root, "task-specific/advisor /gpt4o.md", "advisor")
+	writePrompt(t, root, "task-specific/advisor /gpt4o-v2.md", "advisor, edited")
+	writePrompt(t, root, "task-specific/assistant-v2/gemini-pro2.5.md", "assistant two")
+	writePrompt(t, root, "task-specific/assistant-v3/gemini-pro2.5.md", "assistant three")
+	writePrompt(t, root, "system/meta.md", "---\nid: meta\nversion: \"2.0\"\ntags: [meta]\n---\nmeta body")
+	writePrompt(t, root, "system/notes.txt", "ignored")
+
+	lib, err := LoadLibrary(root)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"meta", "task-specific/advisor/gpt4o", "task-specific/assistant/gemini-pro2.5", "task-specific/mql5"}, lib.IDs())
+	assert.Len(t, lib.All(), 8)
+	assert.Empty(t, lib.Problems())
+
+	t.Run("latest version", func(t *testing.T) {
+		t.Parallel()
+		p, err := lib.Get("task-specific/mql5", "")
+		require.NoError(t, err)
+		assert.Equal(t, "v10", p.Version)
+		assert.Equal(t, "tenth", p.Body)
+		assert.False(t, p.HasFrontMatter)
+	})
+
+	t.Run("specific version", func(t *testing.T) {
+		t.Parallel()
+		p, err := lib.Get("task-specific/mql5", "v3")
+		require.NoError(t, err)
+		assert.Equal(t, "task-specific/mql5/v3.md", p.Path)
+	})
+
+	t.Run("versions are ordered numerically", func(t *testing.T) {
+		t.Parallel()
+		var versions []string
+		for _, p := range lib.Versions("task-specific/mql5") {
+			versions = append(versions, p.Version)
+		}
+		assert.Equal(t, []string{"v2", "v3", "v10"}, versions)
+	})
+
+	t.Run("target model inferred from file name", func(t *testing.T) {
+		t.Parallel()
+		p, err := lib.Get("task-specific/advisor/gpt4o", "")
+		require.NoError(t, err)
+		assert.Equal(t, "gpt4o", p.TargetModel)
+	})
+
+	t.Run("version suffix", func(t *testing.T) {
+		t.Parallel()
+		p, err := lib.Get("task-specific/advisor/gpt4o", "")
+		require.NoError(t, err)
+		assert.Equal(t, "v2", p.Version)
+		assert.Equal(t, "advisor, edited", p.Body)
+		assert.Len(t, lib.Versions("task-specific/advisor/gpt4o"), 2)
+	})
+
+	t.Run("versioned directory", func(t *testing.T) {
+		t.Parallel()
+		versions := lib.Versions("task-specific/assistant/gemini-pro2.5")
+		require.Len(t, versions, 2)
+		assert.Equal(t, "v2", versions[0].Version)
+		assert.Equal(t, "v3", versions[1].Version)
+		assert.Equal(t, "assistant three", versions[1].Body)
+		assert.Equal(t, "gemini-pro2.5", versions[1].TargetModel)
+	})
+
+	t.Run("front matter wins over layout", func(t *testing.T) {
+		t.Parallel()
+		p, err := lib.Get("meta", "2.0")
+		require.NoError(t, err)
+		assert.Equal(t, "system/meta.md", p.Path)
+		assert.Equal(t, "meta body", p.Body)
+		assert.Equal(t, []string{"meta"}, p.Tags)
+	})
+
+	t.Run("unknown prompt or version", func(t *testing.T) {
+		t.Parallel()
+		_, err := lib.Get("missing", "")
+		assert.EqualError(t, err, `prompt "missing" not found`)
+		_, err = lib.Get("meta", "9")
+		assert.EqualError(t, err, `prompt "meta" has no version "9"`)
+	})
+}
+
+func TestLoadLibraryDuplicateVersion(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	writePrompt(t, root, "a.md", "---\nid: same\nversion: v1\n---\nfirst")
+	writePrompt(t, root, "b.md", "---\nid: same\nversion: v1\n---\nsecond")
+	writePrompt(t, root, "c.md", "other")
+
+	lib, err := LoadLibrary(root)
+	require.NoError(t, err, "a duplicate does not stop the rest from loading")
+	assert.Equal(t, []string{"c", "same"}, lib.IDs())
+	p, err := lib.Get("same", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "first", p.Body)
+
+	require.Len(t, lib.Problems(), 1)
+	assert.ErrorContains(t, lib.Problems()[0], `duplicate prompt same version "v1" in a.md and b.md`)
+}
+
+func TestLoadLibraryRepositoryPrompts(t *testing.T) {
+	t.Parallel()
+	lib, err := LoadLibrary(filepath.Join("..", "..", "..", "prompts"))
+	require.NoError(t, err)
+
+	assert.Empty(t, lib.Problems())
+
+	p, err := lib.Get("task-specific/expert-MQL5-MetaTrader5-assistant", "")
+	require.NoError(t, err)
+	assert.Equal(t, "v6", p.Version)
+	assert.Equal(t, "task-specific/expert-MQL5-MetaTrader5-assistant-v6/v6.md", p.Path)
+
+	var versions []string
+	for _, p := range lib.Versions("task-specific/expert-MQL5-MetaTrader5-assistant/gemini-pro2.5") {
+		versions = append(versions, p.Version)
+	}
+	assert.Equal(t, []string{"v2", "v3", "v4", "v5", "v6"}, versions)
+}
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1", "v2", -1},
+		{"v10", "v9", 1},
+		{"1.2", "v1.2", 0},
+		{"1.2", "1.2.1", -1},
+		{"", "v1", -1},
+		{"beta", "alpha", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b), "%q vs %q", tt.a, tt.b)
+	}
+}
@@ -0,0 +1,245 @@
+//revive:disable:package-comments,exported
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/fsutil"
+	"gopkg.in/yaml.v3"
+)
+
+// LineageEntry records how one version of a prompt family came to be.
+type LineageEntry struct {
+	Version string `yaml:"version"`
+	// File is the prompt file of this version, relative to the prompts directory.
+	File   string `yaml:"file"`
+	Parent string `yaml:"parent,omitempty"`
+	// Description explains what changed compared to the parent.
+	Description string `yaml:"description,omitempty"`
+	// MetaPrompt is the meta-prompt file used to generate this version, if any.
+	MetaPrompt string    `yaml:"meta_prompt,omitempty"`
+	Model      string    `yaml:"model,omitempty"`
+	Created    time.Time `yaml:"created,omitempty"`
+}
+
+// Lineage is the version history of a prompt family, stored as a YAML manifest.
+type Lineage struct {
+	Family   string         `yaml:"family"`
+	Versions []LineageEntry `yaml:"versions"`
+}
+
+// LineageFile returns the manifest path of family inside dir. The family
+// name must be a plain file name, so the manifest cannot end up outside dir.
+func LineageFile(dir, family string) (string, error) {
+	if err := checkFamily(family); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, family+".yaml"), nil
+}
+
+func checkFamily(family string) error {
+	switch {
+	case family == "":
+		return fmt.Errorf("family name is empty")
+	case strings.ContainsAny(family, `/\`) || !filepath.IsLocal(family):
+		return fmt.Errorf("family name %q must not contain a path", family)
+	}
+	return nil
+}
+
+// LineageFamilies returns the names of the families that have a manifest in dir.
+func LineageFamilies(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var families []string
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".yaml" {
+			families = append(families, strings.TrimSuffix(e.Name(), ".yaml"))
+		}
+	}
+	return families, nil
+}
+
+// LoadLineage reads and validates a lineage manifest.
+func LoadLineage(filename string) (*Lineage, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var l Lineage
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse lineage %q: %w", filename, err)
+	}
+	if err := l.validate(); err != nil {
+		return nil, fmt.Errorf("invalid lineage %q: %w", filename, err)
+	}
+	return &l, nil
+}
+
+// Save writes the manifest to filename, creating its directory if needed.
+func (l *Lineage) Save(filename string) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode lineage: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create lineage directory: %w", err)
+	}
+	if err := fsutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write lineage %q: %w", filename, err)
+	}
+	return nil
+}
+
+// Add records a new version. Its parent, if set, must already be recorded.
+func (l *Lineage) Add(entry LineageEntry) error {
+	if entry.Version == "" || entry.File == "" {
+		return fmt.Errorf("lineage entry needs a version and a file")
+	}
+	if _, ok := l.Entry(entry.Version); ok {
+		return fmt.Errorf("version %q already recorded", entry.Version)
+	}
+	if entry.Parent != "" {
+		if _, ok := l.Entry(entry.Parent); !ok {
+			return fmt.Errorf("parent version %q not recorded", entry.Parent)
+		}
+	}
+	l.Versions = append(l.Versions, entry)
+	return nil
+}
+
+// Entry returns the entry of version.
+func (l *Lineage) Entry(version string) (LineageEntry, bool) {
+	for _, e := range l.Versions {
+		if e.Version == version {
+			return e, true
+		}
+	}
+	return LineageEntry{}, false
+}
+
+// Children returns the versions derived directly from version; an empty
+// version returns the roots of the family.
+func (l *Lineage) Children(version string) []LineageEntry {
+	var children []LineageEntry
+	for _, e := range l.Versions {
+		if e.Parent == version {
+			children = append(children, e)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return CompareVersions(children[i].Version, children[j].Version) < 0
+	})
+	return children
+}
+
+// Ancestry returns the chain of versions from the root down to version.
+func (l *Lineage) Ancestry(version string) ([]LineageEntry, error) {
+	var chain []LineageEntry
+	for version != "" {
+		e, ok := l.Entry(version)
+		if !ok {
+			return nil, fmt.Errorf("version %q not recorded", version)
+		}
+		chain = append([]LineageEntry{e}, chain...)
+		version = e.Parent
+	}
+	return chain, nil
+}
+
+// WriteTree renders the history of the family as an indented tree.
+func (l *Lineage) WriteTree(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, l.Family); err != nil {
+		return err
+	}
+	return l.writeChildren(w, "", "")
+}
+
+func (l *Lineage) writeChildren(w io.Writer, parent, indent string) error {
+	children := l.Children(parent)
+	for i, e := range children {
+		branch, next := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, next = "└── ", "    "
+		}
+		if _, err := fmt.Fprintf(w, "%s%s%s\n", indent, branch, e.summary()); err != nil {
+			return err
+		}
+		if err := l.writeChildren(w, e.Version, indent+next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e LineageEntry) summary() string {
+	parts := []string{e.Version, e.File}
+	var details []string
+	if e.Model != "" {
+		details = append(details, e.Model)
+	}
+	if e.MetaPrompt != "" {
+		details = append(details, "via "+e.MetaPrompt)
+	}
+	if !e.Created.IsZero() {
+		details = append(details, e.Created.Format(time.DateOnly))
+	}
+	if len(details) > 0 {
+		parts = append(parts, "("+strings.Join(details, ", ")+")")
+	}
+	if e.Description != "" {
+		parts = append(parts, "- "+e.Description)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (l *Lineage) validate() error {
+	if err := checkFamily(l.Family); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, e := range l.Versions {
+		if e.Version == "" || e.File == "" {
+			return fmt.Errorf("every version needs a version and a file")
+		}
+		if seen[e.Version] {
+			return fmt.Errorf("version %q recorded twice", e.Version)
+		}
+		seen[e.Version] = true
+	}
+
+	for _, e := range l.Versions {
+		if e.Parent != "" && !seen[e.Parent] {
+			return fmt.Errorf("version %q has unknown parent %q", e.Version, e.Parent)
+		}
+		visited := map[string]bool{}
+		for v := e.Version; v != ""; {
+			if visited[v] {
+				return fmt.Errorf("version %q is part of a parent cycle", e.Version)
+			}
+			visited[v] = true
+			parent, _ := l.Entry(v)
+			v = parent.Parent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package prompts
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLineage(t *testing.T) *Lineage {
+	t.Helper()
+	l := &Lineage{Family: "mql5-assistant"}
+	require.NoError(t, l.Add(LineageEntry{Version: "v1", File: "task-specific/mql5/v1.md", Model: "models/gemini-2.5-pro"}))
+	require.NoError(t, l.Add(LineageEntry{Version: "v2", File: "task-specific/mql5/v2.md", Parent: "v1", Description: "Add error handling rules"}))
+	require.NoError(t, l.Add(LineageEntry{Version: "v2-flash", File: "task-specific/mql5/v2-flash.md", Parent: "v1"}))
+	require.NoError(t, l.Add(LineageEntry{
+		Version:    "v3",
+		File:       "task-specific/mql5/v3.md",
+		Parent:     "v2",
+		MetaPrompt: "system/meta-prompt-for-edits.md",
+		Created:    time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC),
+	}))
+	return l
+}
+
+func TestLineageAdd(t *testing.T) {
+	t.Parallel()
+	l := newTestLineage(t)
+
+	assert.EqualError(t, l.Add(LineageEntry{Version: "v1", File: "x.md"}), `version "v1" already recorded`)
+	assert.EqualError(t, l.Add(LineageEntry{Version: "v9", File: "x.md", Parent: "v8"}), `parent version "v8" not recorded`)
+	assert.EqualError(t, l.Add(LineageEntry{Version: "v9"}), "lineage entry needs a version and a file")
+}
+
+func TestLineageAncestry(t *testing.T) {
+	t.Parallel()
+	l := newTestLineage(t)
+
+	chain, err := l.Ancestry("v3")
+	require.NoError(t, err)
+	var versions []string
+	for _, e := range chain {
+		versions = append(versions, e.Version)
+	}
+	assert.Equal(t, []string{"v1", "v2", "v3"}, versions)
+
+	_, err = l.Ancestry("v7")
+	assert.EqualError(t, err, `version "v7" not recorded`)
+}
+
+func TestLineageWriteTree(t *testing.T) {
+	t.Parallel()
+	l := newTestLineage(t)
+
+	var sb strings.Builder
+	require.NoError(t, l.WriteTree(&sb))
+	assert.Equal(t, `mql5-assistant
+└── v1 task-specific/mql5/v1.md (models/gemini-2.5-pro)
+    ├── v2 task-specific/mql5/v2.md - Add error handling rules
+    │   └── v3 task-specific/mql5/v3.md (via system/meta-prompt-for-edits.md, 2025-07-01)
+    └── v2-flash task-specific/mql5/v2-flash.md
+`, sb.String())
+}
+
+func TestLineageSaveLoad(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	l := newTestLineage(t)
+	filename, err := LineageFile(filepath.Join(dir, "lineage"), l.Family)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Save(filename))
+	loaded, err := LoadLineage(filename)
+	require.NoError(t, err)
+	assert.Equal(t, l, loaded)
+
+	families, err := LineageFamilies(filepath.Join(dir, "lineage"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mql5-assistant"}, families)
+}
+
+func TestLineageFile(t *testing.T) {
+	t.Parallel()
+	filename, err := LineageFile("lineage", "mql5-assistant")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("lineage", "mql5-assistant.yaml"), filename)
+
+	for _, family := range []string{"", "../escape", "nested/family", `nested\family`, "/abs", ".."} {
+		_, err := LineageFile("lineage", family)
+		assert.Error(t, err, family)
+	}
+}
+
+func TestLoadLineageInvalid(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"family name is empty":                       "versions: []\n",
+		`family name "../f" must not contain a path`: "family: ../f\nversions: []\n",
+		`version "v1" recorded twice`:                "family: f\nversions:\n  - {version: v1, file: a.md}\n  - {version: v1, file: b.md}\n",
+		`version "v2" has unknown parent "v0"`:       "family: f\nversions:\n  - {version: v2, file: a.md, parent: v0}\n",
+		`version "a" is part of a parent cycle`:      "family: f\nversions:\n  - {version: a, file: a.md, parent: b}\n  - {version: b, file: b.md, parent: a}\n",
+	}
+	for want, content := range tests {
+		filename := filepath.Join(dir, "f.yaml")
+		writePrompt(t, dir, "f.yaml", content)
+		_, err := LoadLineage(filename)
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestLoadLineageRepositoryManifests(t *testing.T) {
+	t.Parallel()
+	promptsDir := filepath.Join("..", "..", "..", "prompts")
+	families, err := LineageFamilies(filepath.Join(promptsDir, "lineage"))
+	require.NoError(t, err)
+	assert.Contains(t, families, "expert-MQL5-MetaTrader5-assistant")
+
+	for _, family := range families {
+		filename, err := LineageFile(filepath.Join(promptsDir, "lineage"), family)
+		require.NoError(t, err)
+		l, err := LoadLineage(filename)
+		require.NoError(t, err)
+		for _, e := range l.Versions {
+			assert.FileExists(t, filepath.Join(promptsDir, filepath.FromSlash(e.File)), "%s %s", family, e.Version)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+//revive:disable:package-comments,exported
+package prompts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Parameter types understood by the renderer. An empty type means string.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+	TypeList   = "list"
+)
+
+// maxIncludeDepth guards against runaway include chains.
+const maxIncludeDepth = 10
+
+// Vars holds template variables given as name=value pairs. It implements
+// flag.Value so commands can accept repeated -var flags.
+type Vars map[string]string
+
+func (v Vars) String() string {
+	pairs := make([]string, 0, len(v))
+	for name, value := range v {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v Vars) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("variable %q must have the form name=value", s)
+	}
+	v[strings.TrimSpace(name)] = value
+	return nil
+}
+
+// Renderer renders prompt bodies as Go text/template templates. Variables are
+// referenced as {{ .name }} and shared fragments are pulled in with
+// {{ include "name" }}, which loads name (".md" is optional) from PartialsDir.
+// Names are relative to PartialsDir and may not leave it. Only prompts that
+// opt in are templates: those that declare parameters or set template in
+// their front matter, and any prompt given variables. Other prompts pass
+// through unchanged, so a literal "{{" in plain text is not an error.
+type Renderer struct {
+	PartialsDir string
+	// IgnoreUnknown skips the unknown variable check, for callers that share
+	// one set of variables across several prompts.
+	IgnoreUnknown bool
+}
+
+// RenderFile reads a prompt file, renders it with vars and returns the text
+// prepared by Clean, ready to be sent to the model.
+func (r *Renderer) RenderFile(filename string, vars Vars) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return r.RenderText(filepath.Base(filename), data, vars)
+}
+
+// RenderText is RenderFile for prompt text that does not come from a file,
+// such as stdin or a command line argument. name is used in error messages.
+func (r *Renderer) RenderText(name string, data []byte, vars Vars) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("prompt %q is not valid UTF-8", name)
+	}
+
+	meta, body, _, err := ParseFrontMatter(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	text, err := r.Render(&Prompt{Metadata: meta, Path: name, Body: body}, vars)
+	if err != nil {
+		return "", err
+	}
+	return Clean(text), nil
+}
+
+// Render renders the body of p. When p declares parameters, vars are checked
+// against them: unknown names and missing required values are errors,
+// defaults fill in unset values and each value is converted to its declared
+// type. Prompts without declared parameters receive vars as plain strings.
+func (r *Renderer) Render(p *Prompt, vars Vars) (string, error) {
+	if !p.IsTemplate() && len(vars) == 0 {
+		return p.Body, nil
+	}
+	data, err := bindVars(p.Parameters, vars, r.IgnoreUnknown)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", p.Path, err)
+	}
+	return r.execute(p.Path, p.Body, data, nil)
+}
+
+func (r *Renderer) execute(name, body string, data map[string]any, stack []string) (string, error) {
+	if len(stack) >= maxIncludeDepth {
+		return "", fmt.Errorf("include depth exceeds %d: %s", maxIncludeDepth, strings.Join(stack, " -> "))
+	}
+	stack = append(stack, name)
+
+	funcs := template.FuncMap{
+		"include": func(partial string) (string, error) {
+			return r.include(partial, data, stack)
+		},
+		"join": strings.Join,
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func (r *Renderer) include(partial string, data map[string]any, stack []string) (string, error) {
+	if r.PartialsDir == "" {
+		return "", fmt.Errorf("cannot include %q: no partials directory configured", partial)
+	}
+	if !filepath.IsLocal(filepath.FromSlash(partial)) {
+		return "", fmt.Errorf("cannot include %q: partials must be inside %s", partial, r.PartialsDir)
+	}
+	if filepath.Ext(partial) == "" {
+		partial += ".md"
+	}
+	if slices.Contains(stack, partial) {
+		return "", fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), partial)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(r.PartialsDir, filepath.FromSlash(partial)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read partial %q: %w", partial, err)
+	}
+	_, body, _, err := ParseFrontMatter(raw)
+	if err != nil {
+		return "", fmt.Errorf("partial %q: %w", partial, err)
+	}
+
+	text, err := r.execute(partial, body, data, stack)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(text, "\n"), nil
+}
+
+// bindVars builds the template data from the declared parameters and the given variables.
+func bindVars(params []Parameter, vars Vars, ignoreUnknown bool) (map[string]any, error) {
+	data := map[string]any{}
+	if len(params) == 0 {
+		for name, value := range vars {
+			data[name] = value
+		}
+		return data, nil
+	}
+
+	declared := map[string]bool{}
+	var missing []string
+	for _, p := range params {
+		declared[p.Name] = true
+
+		raw, ok := vars[p.Name]
+		if !ok && p.Default != nil {
+			raw, ok = defaultString(p.Default), true
+		}
+		if !ok {
+			if p.Required {
+				missing = append(missing, p.Name)
+				continue
+			}
+			raw = ""
+		}
+
+		value, err := convert(p, raw, ok)
+		if err != nil {
+			return nil, err
+		}
+		data[p.Name] = value
+	}
+
+	var unknown []string
+	for name := range vars {
+		if !declared[name] && !ignoreUnknown {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	switch {
+	case len(missing) > 0:
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	case len(unknown) > 0:
+		return nil, fmt.Errorf("unknown variables: %s", strings.Join(unknown, ", "))
+	}
+	return data, nil
+}
+
+// defaultString turns a YAML default into the string form variables are given in.
+func defaultString(v any) string {
+	if items, ok := v.([]any); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// convert parses raw according to the parameter type. Unset optional
+// parameters become the zero value of their type.
+func convert(p Parameter, raw string, set bool) (any, error) {
+	switch p.Type {
+	case "", TypeString:
+		return raw, nil
+	case TypeInt:
+		if !set {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %q is not an int", p.Name, raw)
+		}
+		return n, nil
+	case TypeFloat:
+		if !set {
+			return 0.0, nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %q is not a float", p.Name, raw)
+		}
+		return f, nil
+	case TypeBool:
+		if !set {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("variable %q: %q is not a bool", p.Name, raw)
+		}
+		return b, nil
+	case TypeList:
+		if !set || strings.TrimSpace(raw) == "" {
+			return []string{}, nil
+		}
+		items := strings.Split(raw, ",")
+		for i, item := range items {
+			items[i] = strings.TrimSpace(item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("variable %q has unsupported type %q", p.Name, p.Type)
+	}
+}
@@ -0,0 +1,180 @@
+package prompts
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	partials := t.TempDir()
+	writePrompt(t, partials, "output-format.md", "---\nid: partials/output-format\n---\n## Output Format\nAnswer in {{ .language }}.\n")
+	writePrompt(t, partials, "loop-a.md", `{{ include "loop-b" }}`)
+	writePrompt(t, partials, "loop-b.md", `{{ include "loop-a" }}`)
+	r := &Renderer{PartialsDir: partials}
+
+	params := []Parameter{
+		{Name: "task", Required: true},
+		{Name: "language", Default: "English"},
+		{Name: "examples", Type: TypeInt, Default: 2},
+		{Name: "strict", Type: TypeBool},
+		{Name: "topics", Type: TypeList, Default: []any{"mql5", "trading"}},
+		{Name: "temperature", Type: TypeFloat},
+	}
+	prompt := func(body string) *Prompt {
+		return &Prompt{Metadata: Metadata{Parameters: params}, Path: "test.md", Body: body}
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		vars    Vars
+		want    string
+		wantErr string
+	}{
+		{
+			name: "defaults and types",
+			body: "{{ .task }} in {{ .language }} with {{ .examples }} examples on {{ join .topics \" and \" }}{{ if .strict }}!{{ end }}",
+			vars: Vars{"task": "Write code"},
+			want: "Write code in English with 2 examples on mql5 and trading",
+		},
+		{
+			name: "values override defaults",
+			body: "{{ .language }} {{ .examples }} {{ .strict }} {{ .topics }} {{ .temperature }}",
+			vars: Vars{"task": "x", "language": "German", "examples": "5", "strict": "true", "topics": "a, b", "temperature": "0.5"},
+			want: "German 5 true [a b] 0.5",
+		},
+		{
+			name: "include shares variables",
+			body: "{{ .task }}\n\n{{ include \"output-format\" }}\n",
+			vars: Vars{"task": "Explain", "language": "French"},
+			want: "Explain\n\n## Output Format\nAnswer in French.\n",
+		},
+		{
+			name:    "missing required variable",
+			body:    "{{ .task }}",
+			vars:    Vars{},
+			wantErr: "test.md: missing required variables: task",
+		},
+		{
+			name:    "unknown variable",
+			body:    "{{ .task }}",
+			vars:    Vars{"task": "x", "tsk": "y"},
+			wantErr: "test.md: unknown variables: tsk",
+		},
+		{
+			name:    "wrong type",
+			body:    "{{ .examples }}",
+			vars:    Vars{"task": "x", "examples": "many"},
+			wantErr: `test.md: variable "examples": "many" is not an int`,
+		},
+		{
+			name:    "undeclared reference",
+			body:    "{{ .audience }}",
+			vars:    Vars{"task": "x"},
+			wantErr: "failed to render template",
+		},
+		{
+			name:    "include cycle",
+			body:    `{{ include "loop-a" }}`,
+			vars:    Vars{"task": "x"},
+			wantErr: "include cycle: test.md -> loop-a.md -> loop-b.md -> loop-a.md",
+		},
+		{
+			name:    "missing partial",
+			body:    `{{ include "nope" }}`,
+			vars:    Vars{"task": "x"},
+			wantErr: `failed to read partial "nope.md"`,
+		},
+		{
+			name:    "partial outside the partials directory",
+			body:    `{{ include "../secret" }}`,
+			vars:    Vars{"task": "x"},
+			wantErr: `cannot include "../secret": partials must be inside`,
+		},
+		{
+			name:    "absolute partial path",
+			body:    `{{ include "/etc/passwd" }}`,
+			vars:    Vars{"task": "x"},
+			wantErr: `cannot include "/etc/passwd": partials must be inside`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := r.Render(prompt(tt.body), tt.vars)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRenderUndeclaredParameters(t *testing.T) {
+	t.Parallel()
+	r := &Renderer{}
+
+	got, err := r.Render(&Prompt{Body: "Hello {{ .name }}, [Your Company] stays as is."}, Vars{"name": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Ada, [Your Company] stays as is.", got)
+
+	shared := &Renderer{IgnoreUnknown: true}
+	got, err = shared.Render(&Prompt{Metadata: Metadata{Parameters: []Parameter{{Name: "name"}}}, Body: "{{ .name }}"}, Vars{"name": "Ada", "other": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", got)
+
+	_, err = r.Render(&Prompt{Metadata: Metadata{Template: true}, Body: `{{ include "x" }}`}, nil)
+	assert.ErrorContains(t, err, "no partials directory configured")
+}
+
+func TestRenderPlainText(t *testing.T) {
+	t.Parallel()
+	r := &Renderer{}
+
+	body := "Format the output as `{{ .Name }}` and close every {{ block."
+	got, err := r.Render(&Prompt{Body: body}, nil)
+	require.NoError(t, err, "prompts that do not opt in are not templates")
+	assert.Equal(t, body, got)
+
+	_, err = r.Render(&Prompt{Body: body}, Vars{"name": "Ada"})
+	assert.ErrorContains(t, err, "failed to parse template", "variables opt in")
+
+	got, err = r.RenderText("stdin", []byte("---\ntemplate: true\n---\n{{ if true }}yes{{ end }}\n"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "yes", got)
+}
+
+func TestRenderFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "prompt.md")
+	require.NoError(t, os.WriteFile(filename, []byte("---\nparameters:\n  - name: who\n    required: true\n---\n\n# Greeting\n\nHello {{ .who }}!\n"), 0644))
+
+	got, err := (&Renderer{}).RenderFile(filename, Vars{"who": "world"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello world!", got)
+}
+
+func TestVarsFlag(t *testing.T) {
+	t.Parallel()
+	vars := Vars{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(vars, "var", "")
+
+	require.NoError(t, fs.Parse([]string{"-var", "a=1", "-var", "b = x=y"}))
+	assert.Equal(t, Vars{"a": "1", "b": " x=y"}, vars)
+	assert.Equal(t, "a=1,b= x=y", vars.String())
+	assert.Error(t, fs.Parse([]string{"-var", "novalue"}))
+}
@@ -0,0 +1,72 @@
+//revive:disable:package-comments,exported
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	versionFile   = regexp.MustCompile(`^v(\d+)$`)
+	versionSuffix = regexp.MustCompile(`-v(\d+)$`)
+)
+
+// FileVersion returns the version a file name carries, "v4" for both "v4.md"
+// and "advisor-v4.md", or "" if it has none.
+func FileVersion(filename string) string {
+	stem := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	if m := versionFile.FindStringSubmatch(stem); m != nil {
+		return "v" + m[1]
+	}
+	if m := versionSuffix.FindStringSubmatch(stem); m != nil {
+		return "v" + m[1]
+	}
+	return ""
+}
+
+// NextVersionFile returns an unused file name for a new version of filename in
+// the same directory. Version files ("v4.md") continue the highest vN number
+// of their directory, which need not exist yet; other files get a "-v2",
+// "-v3", ... suffix.
+func NextVersionFile(filename string) (string, error) {
+	dir := filepath.Dir(filename)
+	ext := filepath.Ext(filename)
+	stem := strings.TrimSuffix(filepath.Base(filename), ext)
+
+	if versionFile.MatchString(stem) {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		highest := 0
+		for _, e := range entries {
+			m := versionFile.FindStringSubmatch(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())))
+			if m != nil {
+				n, _ := strconv.Atoi(m[1])
+				highest = max(highest, n)
+			}
+		}
+		return filepath.Join(dir, fmt.Sprintf("v%d%s", highest+1, ext)), nil
+	}
+
+	n := 2
+	if m := versionSuffix.FindStringSubmatch(stem); m != nil {
+		current, _ := strconv.Atoi(m[1])
+		n = current + 1
+		stem = strings.TrimSuffix(stem, m[0])
+	}
+	for ; ; n++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s-v%d%s", stem, n, ext))
+		if _, err := os.Stat(candidate); errors.Is(err, fs.ErrNotExist) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
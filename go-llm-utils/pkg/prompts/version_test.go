@@ -0,0 +1,46 @@
+package prompts
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextVersionFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"v2.md", "v3.md", "v10.md", "gpt4o.md", "gemini-pro2.5.md", "gemini-pro2.5-v2.md"} {
+		writePrompt(t, dir, name, "x")
+	}
+
+	tests := map[string]string{
+		"v3.md":               "v11.md",
+		"gpt4o.md":            "gpt4o-v2.md",
+		"gemini-pro2.5.md":    "gemini-pro2.5-v3.md",
+		"gemini-pro2.5-v2.md": "gemini-pro2.5-v3.md",
+	}
+	for from, want := range tests {
+		got, err := NextVersionFile(filepath.Join(dir, from))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, want), got, from)
+	}
+
+	got, err := NextVersionFile(filepath.Join(dir, "new-family", "v1.md"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "new-family", "v1.md"), got)
+}
+
+func TestFileVersion(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"v4.md":                   "v4",
+		"dir/gemini-pro2.5-v2.md": "v2",
+		"gpt4o.md":                "",
+		"v2-notes.md":             "",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, FileVersion(name), name)
+	}
+}
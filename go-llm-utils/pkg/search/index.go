@@ -0,0 +1,249 @@
+//revive:disable:package-comments,exported
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/softwaredevelop/prompt-engineering/go-llm-utils/pkg/fsutil"
+)
+
+// indexVersion is bumped whenever the on-disk format changes incompatibly.
+const indexVersion = 1
+
+// maxEmbedChars caps the amount of text sent to the embedding model per file.
+const maxEmbedChars = 8000
+
+// Embedder turns text into embedding vectors.
+type Embedder interface {
+	// Name identifies the embedding model, so vectors from different models are never mixed.
+	Name() string
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+// Document is a single indexed file.
+type Document struct {
+	Path      string         `json:"path"`
+	Hash      string         `json:"hash"`
+	Title     string         `json:"title"`
+	Terms     map[string]int `json:"terms"`
+	Length    int            `json:"length"`
+	Embedding []float32      `json:"embedding,omitempty"`
+}
+
+// Index holds the keyword and embedding index of a directory of markdown files.
+type Index struct {
+	Version        int                  `json:"version"`
+	EmbeddingModel string               `json:"embeddingModel,omitempty"`
+	Documents      map[string]*Document `json:"documents"`
+}
+
+// SyncStats reports what changed during Sync.
+type SyncStats struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+	Embedded  int
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{Version: indexVersion, Documents: map[string]*Document{}}
+}
+
+// Load reads an index from path. A missing file or an index written by an
+// incompatible version yields an empty index, which Sync then rebuilds.
+func Load(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewIndex(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index %q: %w", path, err)
+	}
+
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("failed to parse index %q: %w", path, err)
+	}
+	if idx.Version != indexVersion || idx.Documents == nil {
+		return NewIndex(), nil
+	}
+	return &idx, nil
+}
+
+// Save writes the index to path as JSON.
+func (idx *Index) Save(path string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := fsutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index %q: %w", path, err)
+	}
+	return nil
+}
+
+// Sync brings the index up to date with the markdown files under dir. Only
+// files whose content hash changed are re-tokenized and re-embedded; files
+// that no longer exist are dropped. The embedder may be nil, in which case
+// only the keyword index is maintained.
+func (idx *Index) Sync(ctx context.Context, dir string, embedder Embedder) (SyncStats, error) {
+	var stats SyncStats
+
+	if embedder != nil && idx.EmbeddingModel != embedder.Name() {
+		for _, doc := range idx.Documents {
+			doc.Embedding = nil
+		}
+		idx.EmbeddingModel = embedder.Name()
+	}
+
+	texts := map[string]string{}
+	seen := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %q: %w", path, err)
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+
+		doc, ok := idx.Documents[rel]
+		switch {
+		case !ok:
+			stats.Added++
+		case doc.Hash != hash:
+			stats.Updated++
+		default:
+			stats.Unchanged++
+			if embedder != nil && doc.Embedding == nil {
+				texts[rel] = string(data)
+			}
+			return nil
+		}
+
+		text := string(data)
+		terms := Tokenize(text)
+		idx.Documents[rel] = &Document{
+			Path:   rel,
+			Hash:   hash,
+			Title:  title(text, rel),
+			Terms:  termCounts(terms),
+			Length: len(terms),
+		}
+		texts[rel] = text
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to scan %q: %w", dir, err)
+	}
+
+	for rel := range idx.Documents {
+		if !seen[rel] {
+			delete(idx.Documents, rel)
+			stats.Removed++
+		}
+	}
+
+	if embedder == nil || len(texts) == 0 {
+		return stats, nil
+	}
+
+	paths := make([]string, 0, len(texts))
+	for rel := range texts {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+
+	inputs := make([]string, len(paths))
+	for i, rel := range paths {
+		inputs[i] = embeddingInput(idx.Documents[rel].Title, texts[rel])
+	}
+
+	vectors, err := embedder.EmbedDocuments(ctx, inputs)
+	if err != nil {
+		return stats, fmt.Errorf("failed to embed documents: %w", err)
+	}
+	if len(vectors) != len(paths) {
+		return stats, fmt.Errorf("embedder returned %d vectors for %d documents", len(vectors), len(paths))
+	}
+	for i, rel := range paths {
+		idx.Documents[rel].Embedding = vectors[i]
+	}
+	stats.Embedded = len(paths)
+
+	return stats, nil
+}
+
+func embeddingInput(title, text string) string {
+	input := title + "\n\n" + text
+	if len(input) > maxEmbedChars {
+		// Cut at a rune boundary so the input stays valid UTF-8.
+		end := maxEmbedChars
+		for end > 0 && !utf8.RuneStart(input[end]) {
+			end--
+		}
+		input = input[:end]
+	}
+	return input
+}
+
+// title returns the markdown heading a file starts with, after any front
+// matter, or the file name without extension. Headings further down name a
+// section rather than the file.
+func title(text, rel string) string {
+	lines := strings.Split(text, "\n")
+	if strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if heading := strings.TrimLeft(line, "#"); heading != line && strings.HasPrefix(heading, " ") {
+			return strings.TrimSpace(heading)
+		}
+		break
+	}
+	return strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+}
+
+func termCounts(terms []string) map[string]int {
+	counts := make(map[string]int, len(terms))
+	for _, t := range terms {
+		counts[t]++
+	}
+	return counts
+}
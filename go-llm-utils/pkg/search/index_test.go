@@ -0,0 +1,193 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbedder maps text onto a tiny vector space keyed by topic words.
+type fakeEmbedder struct {
+	name  string
+	calls int
+}
+
+var topics = []string{"trading", "mql5", "privacy", "greeting"}
+
+func (f *fakeEmbedder) Name() string { return f.name }
+
+func (f *fakeEmbedder) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	f.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = f.vector(text)
+	}
+	return vectors, nil
+}
+
+func (f *fakeEmbedder) EmbedQuery(_ context.Context, text string) ([]float32, error) {
+	return f.vector(text), nil
+}
+
+func (f *fakeEmbedder) vector(text string) []float32 {
+	v := make([]float32, len(topics))
+	lower := strings.ToLower(text)
+	for i, topic := range topics {
+		v[i] = float32(strings.Count(lower, topic))
+	}
+	return v
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func newPromptDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, dir, "task-specific/mql5/v1.md", "# MQL5 Assistant\n\nYou write MQL5 code for MetaTrader trading robots.")
+	writeFile(t, dir, "task-specific/privacy/v1.md", "# Data Protection\n\nYou answer privacy and GDPR questions.")
+	writeFile(t, dir, "user/hello.md", "Hello, what model are you? A greeting.")
+	writeFile(t, dir, "user/notes.txt", "not a prompt")
+	return dir
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := newPromptDir(t)
+	embedder := &fakeEmbedder{name: "fake-1"}
+
+	idx := NewIndex()
+	stats, err := idx.Sync(ctx, dir, embedder)
+	require.NoError(t, err)
+	assert.Equal(t, SyncStats{Added: 3, Embedded: 3}, stats)
+	assert.Equal(t, "MQL5 Assistant", idx.Documents["task-specific/mql5/v1.md"].Title)
+	assert.Equal(t, "hello", idx.Documents["user/hello.md"].Title)
+
+	t.Run("unchanged files are not re-embedded", func(t *testing.T) {
+		calls := embedder.calls
+		stats, err := idx.Sync(ctx, dir, embedder)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStats{Unchanged: 3}, stats)
+		assert.Equal(t, calls, embedder.calls)
+	})
+
+	t.Run("changed and removed files are picked up", func(t *testing.T) {
+		writeFile(t, dir, "task-specific/mql5/v1.md", "# MQL5 Assistant v2\n\nMQL5 trading.")
+		require.NoError(t, os.Remove(filepath.Join(dir, "user/hello.md")))
+
+		stats, err := idx.Sync(ctx, dir, embedder)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStats{Updated: 1, Removed: 1, Unchanged: 1, Embedded: 1}, stats)
+		assert.NotContains(t, idx.Documents, "user/hello.md")
+	})
+
+	t.Run("switching models re-embeds everything", func(t *testing.T) {
+		stats, err := idx.Sync(ctx, dir, &fakeEmbedder{name: "fake-2"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, stats.Embedded)
+		assert.Equal(t, "fake-2", idx.EmbeddingModel)
+	})
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := newPromptDir(t)
+
+	t.Run("keyword only", func(t *testing.T) {
+		t.Parallel()
+		idx := NewIndex()
+		_, err := idx.Sync(ctx, dir, nil)
+		require.NoError(t, err)
+
+		results, err := idx.Search(ctx, "GDPR privacy", nil, 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "task-specific/privacy/v1.md", results[0].Path)
+		assert.InDelta(t, 1.0, results[0].Score, 1e-9)
+	})
+
+	t.Run("hybrid ranking uses embeddings", func(t *testing.T) {
+		t.Parallel()
+		embedder := &fakeEmbedder{name: "fake-1"}
+		idx := NewIndex()
+		_, err := idx.Sync(ctx, dir, embedder)
+		require.NoError(t, err)
+
+		results, err := idx.Search(ctx, "greeting", embedder, 1)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "user/hello.md", results[0].Path)
+		assert.Greater(t, results[0].SemanticScore, 0.0)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		t.Parallel()
+		idx := NewIndex()
+		_, err := idx.Sync(ctx, dir, nil)
+		require.NoError(t, err)
+
+		results, err := idx.Search(ctx, "kubernetes", nil, 0)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestSaveLoad(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := newPromptDir(t)
+	path := filepath.Join(t.TempDir(), "index.json")
+
+	idx, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, idx.Documents)
+
+	_, err = idx.Sync(ctx, dir, &fakeEmbedder{name: "fake-1"})
+	require.NoError(t, err)
+	require.NoError(t, idx.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, idx, loaded)
+}
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"mql5", "metatrader5", "assistant", "v6"},
+		Tokenize("The MQL5 MetaTrader5-assistant, v6!"))
+}
+
+func TestTitle(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"# MQL5 Assistant\n\nBody":                    "MQL5 Assistant",
+		"\n\n## Data Protection\n":                    "Data Protection",
+		"---\nid: x\n---\n# With Front Matter\n":      "With Front Matter",
+		"You are an assistant.\n\n# Output Format\n":  "prompt",
+		"#include <Trade.mqh>\n":                      "prompt",
+		"---\nunclosed front matter\n# Not A Title\n": "prompt",
+	}
+	for text, want := range tests {
+		assert.Equal(t, want, title(text, "dir/prompt.md"), text)
+	}
+}
+
+func TestEmbeddingInputRuneBoundary(t *testing.T) {
+	t.Parallel()
+	input := embeddingInput("t", strings.Repeat("é", maxEmbedChars))
+	assert.LessOrEqual(t, len(input), maxEmbedChars)
+	assert.True(t, utf8.ValidString(input))
+	assert.Equal(t, maxEmbedChars-1, len(input), "the cut backs off to the start of the split rune")
+}
@@ -0,0 +1,167 @@
+//revive:disable:package-comments,exported
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 tuning parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// semanticWeight is the share of the semantic score in the hybrid ranking.
+const semanticWeight = 0.5
+
+// Result is a ranked search hit.
+type Result struct {
+	Path          string
+	Title         string
+	Score         float64
+	KeywordScore  float64
+	SemanticScore float64
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "with": true,
+	"you": true, "your": true,
+}
+
+// Tokenize lowercases text and splits it into keyword terms, dropping stop words.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := fields[:0]
+	for _, f := range fields {
+		if len(f) < 2 || stopWords[f] {
+			continue
+		}
+		terms = append(terms, f)
+	}
+	return terms
+}
+
+// Search ranks the indexed documents against query and returns at most limit
+// results (all matches if limit <= 0). Keyword scores use BM25; when an
+// embedder is given and the index has vectors from the same model, they are
+// blended with the cosine similarity of the query embedding.
+func (idx *Index) Search(ctx context.Context, query string, embedder Embedder, limit int) ([]Result, error) {
+	keyword := idx.bm25(Tokenize(query))
+
+	var semantic map[string]float64
+	if embedder != nil && embedder.Name() == idx.EmbeddingModel {
+		vector, err := embedder.EmbedQuery(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		semantic = map[string]float64{}
+		for path, doc := range idx.Documents {
+			if doc.Embedding != nil {
+				semantic[path] = math.Max(0, cosine(vector, doc.Embedding))
+			}
+		}
+	}
+
+	var results []Result
+	for path, doc := range idx.Documents {
+		r := Result{Path: path, Title: doc.Title, KeywordScore: keyword[path]}
+		if semantic != nil {
+			r.SemanticScore = semantic[path]
+			r.Score = semanticWeight*r.SemanticScore + (1-semanticWeight)*r.KeywordScore
+		} else {
+			r.Score = r.KeywordScore
+		}
+		if r.Score > 0 {
+			results = append(results, r)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// bm25 scores every document against the query terms, normalized so the best match scores 1.
+func (idx *Index) bm25(terms []string) map[string]float64 {
+	scores := map[string]float64{}
+	n := float64(len(idx.Documents))
+	if n == 0 || len(terms) == 0 {
+		return scores
+	}
+
+	var total int
+	for _, doc := range idx.Documents {
+		total += doc.Length
+	}
+	avgLen := float64(total) / n
+	if avgLen == 0 {
+		return scores
+	}
+
+	for _, term := range terms {
+		var df float64
+		for _, doc := range idx.Documents {
+			if doc.Terms[term] > 0 {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for path, doc := range idx.Documents {
+			tf := float64(doc.Terms[term])
+			if tf == 0 {
+				continue
+			}
+			norm := tf + bm25K1*(1-bm25B+bm25B*float64(doc.Length)/avgLen)
+			scores[path] += idf * tf * (bm25K1 + 1) / norm
+		}
+	}
+
+	var best float64
+	for _, s := range scores {
+		best = math.Max(best, s)
+	}
+	if best > 0 {
+		for path := range scores {
+			scores[path] /= best
+		}
+	}
+	return scores
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -1,5 +1,6 @@
-from pathlib import Path
-from typing import Union
+import re
+from pathlib import Path, PurePosixPath
+from typing import Optional, Union
 
 from google.genai.types import GenerateContentResponse
 
@@ -52,6 +53,117 @@ def read_text_from_file(filepath: Union[str, Path]) -> str:
     return Path(filepath).read_text(encoding="utf-8")
 
 
+INCLUDE_DIRECTIVE = re.compile(r'\{\{-?\s*include\s+"([^"]+)"\s*-?\}\}')
+MAX_INCLUDE_DEPTH = 10
+
+
+def split_front_matter(text: str) -> tuple[list[str], str]:
+    """Splits a prompt into its front matter lines and the body.
+
+    Front matter is only recognized when the very first line is "---"; it ends
+    at the next "---" line, as in the Go ParseFrontMatter.
+
+    Args:
+        text: The prompt file content.
+
+    Returns:
+        The front matter lines, empty if there is none, and the body.
+
+    Raises:
+        ValueError: If the front matter is not terminated.
+    """
+    text = text.removeprefix("\ufeff")
+    lines = text.splitlines(keepends=True)
+    if not lines or lines[0].rstrip(" \t\r\n") != "---":
+        return [], text
+
+    for i, line in enumerate(lines[1:], start=1):
+        if line.rstrip(" \t\r\n") == "---":
+            return lines[1:i], "".join(lines[i + 1 :])
+    raise ValueError('front matter is not terminated by "---"')
+
+
+def is_template(front_matter: list[str]) -> bool:
+    """Reports whether front matter opts a prompt in to templating.
+
+    Mirrors Metadata.IsTemplate in the Go prompts package: a prompt is a
+    template when it declares parameters or sets "template: true".
+    """
+    for line in front_matter:
+        key, _, value = line.partition(":")
+        if key == "parameters" or (key == "template" and value.strip() == "true"):
+            return True
+    return False
+
+
+def read_prompt_template(
+    filepath: Union[str, Path], partials_dir: Optional[Union[str, Path]] = None
+) -> str:
+    """Reads a prompt file, removing its front matter and expanding includes.
+
+    Prompts that opt in to templating may pull in shared fragments with
+    {{ include "name" }}, which loads name (".md" is optional) from
+    partials_dir, like the Go prompt renderer. Other template features, such as
+    variables, need the gemini CLI. Prompts that do not opt in are returned as
+    they are, without their front matter.
+
+    Args:
+        filepath: The path to the prompt file.
+        partials_dir: The directory of the partials, usually prompts/partials.
+
+    Returns:
+        The prompt text.
+
+    Raises:
+        FileNotFoundError: If the file or a partial is not found.
+        ValueError: If an include is invalid or the prompt needs variables.
+    """
+    front_matter, body = split_front_matter(
+        Path(filepath).read_text(encoding="utf-8")
+    )
+    if not is_template(front_matter):
+        return body
+    return _expand_includes(body, partials_dir, [Path(filepath).name])
+
+
+def _expand_includes(
+    body: str, partials_dir: Optional[Union[str, Path]], stack: list[str]
+) -> str:
+    if len(stack) > MAX_INCLUDE_DEPTH:
+        raise ValueError(
+            f"include depth exceeds {MAX_INCLUDE_DEPTH}: {' -> '.join(stack)}"
+        )
+
+    def include(match: re.Match) -> str:
+        name = match.group(1)
+        path = PurePosixPath(name)
+        if partials_dir is None:
+            raise ValueError(
+                f"cannot include {name!r}: no partials directory configured"
+            )
+        if path.is_absolute() or ".." in path.parts:
+            raise ValueError(
+                f"cannot include {name!r}: partials must be inside {partials_dir}"
+            )
+        if not path.suffix:
+            name += ".md"
+        if name in stack:
+            raise ValueError(f"include cycle: {' -> '.join(stack)} -> {name}")
+
+        _, partial = split_front_matter(
+            (Path(partials_dir) / name).read_text(encoding="utf-8")
+        )
+        return _expand_includes(partial, partials_dir, stack + [name]).rstrip("\n")
+
+    text = INCLUDE_DIRECTIVE.sub(include, body)
+    if "{{" in text:
+        raise ValueError(
+            f"{stack[-1]} uses template features other than include; "
+            "render it with the gemini CLI"
+        )
+    return text
+
+
 def write_gemini_text_to_markdown(
     response: GenerateContentResponse, output_path: str
 ) -> None:
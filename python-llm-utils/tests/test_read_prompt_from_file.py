@@ -0,0 +1,32 @@
+import json
+import tempfile
+import unittest
+from pathlib import Path
+
+from llm.gemini.utils import read_prompt_from_file
+
+CASES_FILE = (
+    Path(__file__).resolve().parents[2]
+    / "testdata"
+    / "read-prompt-from-file"
+    / "cases.json"
+)
+
+
+class ReadPromptFromFileTest(unittest.TestCase):
+    """Runs the fixtures shared with the Go ReadPromptFromFile tests."""
+
+    def test_shared_cases(self) -> None:
+        cases = json.loads(CASES_FILE.read_text(encoding="utf-8"))
+        self.assertTrue(cases)
+
+        for case in cases:
+            with self.subTest(case["name"]), tempfile.TemporaryDirectory() as tmp:
+                path = Path(tmp) / "prompt.md"
+                path.write_bytes(case["input"].encode("utf-8"))
+
+                self.assertEqual(read_prompt_from_file(str(path)), case["expected"])
+
+
+if __name__ == "__main__":
+    unittest.main()
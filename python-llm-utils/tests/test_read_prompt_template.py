@@ -0,0 +1,56 @@
+import tempfile
+import unittest
+from pathlib import Path
+
+from llm.gemini.utils import read_prompt_template
+
+PROMPTS_DIR = Path(__file__).resolve().parents[2] / "prompts"
+
+
+class ReadPromptTemplateTest(unittest.TestCase):
+    """Checks the include expansion shared with the Go prompt renderer."""
+
+    def setUp(self) -> None:
+        tmp = tempfile.TemporaryDirectory()
+        self.addCleanup(tmp.cleanup)
+        self.dir = Path(tmp.name)
+        self.partials = self.dir / "partials"
+        self.partials.mkdir()
+        (self.partials / "format.md").write_text(
+            "---\nid: partials/format\n---\n## Format\nBe brief.\n", encoding="utf-8"
+        )
+
+    def read(self, text: str) -> str:
+        path = self.dir / "prompt.md"
+        path.write_text(text, encoding="utf-8")
+        return read_prompt_template(path, self.partials)
+
+    def test_include(self) -> None:
+        self.assertEqual(
+            self.read('---\ntemplate: true\n---\nTask.\n\n{{ include "format" }}\n'),
+            "Task.\n\n## Format\nBe brief.\n",
+        )
+
+    def test_plain_text_is_unchanged(self) -> None:
+        text = 'Write `{{ include "format" }}` literally.\n'
+        self.assertEqual(self.read(text), text)
+
+    def test_invalid_includes(self) -> None:
+        for body, message in [
+            ('{{ include "../secret" }}', "partials must be inside"),
+            ('{{ include "/etc/passwd" }}', "partials must be inside"),
+            ("{{ .task }}", "render it with the gemini CLI"),
+        ]:
+            with self.subTest(body), self.assertRaisesRegex(ValueError, message):
+                self.read("---\ntemplate: true\n---\n" + body)
+
+    def test_meta_prompt(self) -> None:
+        text = read_prompt_template(
+            PROMPTS_DIR / "system" / "meta-prompt.md", PROMPTS_DIR / "partials"
+        )
+        self.assertIn("# Guidelines", text)
+        self.assertNotIn("{{", text)
+
+
+if __name__ == "__main__":
+    unittest.main()